@@ -0,0 +1,23 @@
+package mp3
+
+// bitReader reads big-endian bit fields from a byte slice.
+type bitReader struct {
+	b []byte
+	n int // bit offset
+}
+
+// bits reads the next n (<= 32) bits. It panics if there are not enough bits
+// remaining.
+func (r *bitReader) bits(n int) uint32 {
+	var v uint32
+	for ; n > 0; n-- {
+		v = v<<1 | uint32(r.b[r.n/8]>>(7-r.n%8))&1
+		r.n++
+	}
+	return v
+}
+
+// flag reads the next bit.
+func (r *bitReader) flag() bool {
+	return r.bits(1) != 0
+}
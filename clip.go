@@ -0,0 +1,116 @@
+package mp3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+// Clip writes the frames from r overlapping the time range [start, end) to w
+// as an independently playable stream. Tags are not included.
+//
+// If the first frame contains a Xing header, it is written first, updated for
+// the clipped stream (see [TrimGapless]). The encoder delay is kept if the
+// clip starts at the first frame, and the padding is kept if it ends at the
+// last frame. Other info frames (e.g., VBRI) are not included.
+//
+// For Layer III, the first frame of the clip may depend on main data from the
+// preceding frames (i.e., the bit reservoir). In this case, silent frames
+// containing the main data are inserted before it (see [Split]), and their
+// duration is added to the encoder delay (up to the maximum of 4095 samples) so
+// they are skipped during gapless playback.
+func Clip(w io.Writer, r io.ReadSeeker, start, end time.Duration) error {
+	start = max(start, 0)
+	if end <= start {
+		return errors.New("clip end must be after start")
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	rd := NewReader(io.NewSectionReader(seekReaderAt{r}, 0, size), indexBufferSize)
+
+	var (
+		info    []byte // of the xing frame, if any
+		infoH   FrameHeader
+		lame    *LAMEHeader
+		out     []byte // frames after the info frame
+		ix      Index
+		crc     uint16
+		t       time.Duration
+		delay   int
+		padding int
+		first   = true // whether the clip starts at the first frame
+		last    bool   // whether the clip ends at the last frame
+	)
+	for n := 0; ; n++ {
+		if !rd.Next() {
+			last = true
+			break
+		}
+		if n == 0 && rd.IsInfoFrame() {
+			if _, ok := ParseXingHeader(*rd.Header(), rd.Raw()[FrameHeaderSize:]); ok {
+				infoH, info = *rd.Header(), bytes.Clone(rd.Raw())
+				lame, _ = ParseLAMEHeader(infoH, info[FrameHeaderSize:])
+			}
+			continue
+		}
+		if t >= end {
+			break
+		}
+		duration, _ := rd.Header().Duration()
+		if t += duration; t <= start {
+			first = false
+			continue
+		}
+		if len(out) == 0 {
+			if begin, ok := rd.MainDataBegin(); ok && begin != 0 {
+				reservoir := rd.reservoir[max(len(rd.reservoir)-begin, 0):]
+				if out, err = primingFrames(out, *rd.Header(), len(rd.Raw()), begin, reservoir); err != nil {
+					return err
+				}
+				sampleCount, _ := rd.Header().SampleCount()
+				frameSize := len(rd.Raw())
+				for i := 0; i < len(out); i += frameSize {
+					if err := ix.add(int64(len(info)+i), frameSize, rd.Header()); err != nil {
+						return err
+					}
+					delay += sampleCount
+				}
+				crc = crc16LAME(crc, out)
+			}
+		}
+		if err := ix.add(int64(len(info)+len(out)), len(rd.Raw()), rd.Header()); err != nil {
+			return err
+		}
+		out = append(out, rd.Raw()...)
+		crc = crc16LAME(crc, rd.Raw())
+	}
+	if err := rd.Err(); err != nil {
+		return err
+	}
+	if len(ix.sizes) == 0 {
+		return errors.New("no frames in clip")
+	}
+
+	if info != nil {
+		if lame != nil {
+			if first {
+				delay += int(lame.EncoderDelay)
+			}
+			if last {
+				padding = int(lame.PaddingSamples)
+			}
+		}
+		frame, err := updateInfoFrame(infoH, info, &ix, int64(len(info)+len(out)), crc, delay, padding)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,152 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClip(t *testing.T) {
+	audio, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(audio[:FrameHeaderSize]); err != nil {
+		panic(err)
+	}
+	h.BitrateIndex = 9
+
+	var (
+		frames [][]byte
+		times  []time.Duration
+	)
+	r := NewReader(bytes.NewReader(audio), 16384)
+	for r.Next() {
+		frames = append(frames, bytes.Clone(r.Raw()))
+		times = append(times, r.Time()-mustDuration(r.Header()))
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	d, n := mustDuration(r.Header()), len(frames)
+	samples, _ := r.Header().SampleCount()
+
+	x := XingHeader{Tag: "Xing", Flags: XingFrames | XingBytes | XingTOC, Frames: uint32(n)}
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+
+	for _, tc := range []struct {
+		Name       string
+		Buf        []byte
+		Start, End time.Duration
+		First, N   int // of the original frames
+		Info       bool
+	}{
+		{"all", slices.Concat(testInfoFrame(h, x, testLAME), audio, tag), 0, r.Time() + time.Second, 0, n, true},
+		{"start", slices.Concat(testInfoFrame(h, x, testLAME), audio), -time.Second, times[10] + d/2, 0, 11, true},
+		{"middle", slices.Concat(testInfoFrame(h, x, testLAME), audio), times[10] + d/2, times[20], 10, 10, true},
+		{"end", slices.Concat(testInfoFrame(h, x, testLAME), audio), times[n-10], r.Time(), n - 10, 10, true},
+		{"no info", audio, times[10], times[20] + 1, 10, 11, false},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			var w bytes.Buffer
+			if err := Clip(&w, bytes.NewReader(tc.Buf), tc.Start, tc.End); err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+
+			var (
+				act  [][]byte
+				info []byte
+			)
+			r := NewReader(bytes.NewReader(w.Bytes()), 16384)
+			for r.Next() {
+				if len(act) == 0 && info == nil && r.IsInfoFrame() {
+					info = bytes.Clone(r.Raw())
+					continue
+				}
+				if _, ok := r.LogicalMainData(); !ok {
+					t.Errorf("frame %d: missing main data", len(act))
+				}
+				act = append(act, bytes.Clone(r.Raw()))
+			}
+			if err := r.Err(); err != nil {
+				t.Fatalf("read frames: %v", err)
+			}
+			if _, ok := r.ID3v1(); ok {
+				t.Errorf("expected no id3v1 tag")
+			}
+
+			if len(act) < tc.N {
+				t.Fatalf("expected at least %d frames, got %d", tc.N, len(act))
+			}
+			priming := len(act) - tc.N
+			if tc.First == 0 && priming != 0 {
+				t.Errorf("expected no priming frames, got %d", priming)
+			}
+			for i, exp := range frames[tc.First : tc.First+tc.N] {
+				if !bytes.Equal(act[priming+i], exp) {
+					t.Errorf("frame %d: incorrect data", i)
+				}
+			}
+
+			if !tc.Info {
+				if info != nil {
+					t.Errorf("unexpected info frame")
+				}
+				return
+			}
+			if info == nil {
+				t.Fatalf("missing info frame")
+			}
+			var ih FrameHeader
+			ih.decode(info)
+			x, ok := ParseXingHeader(ih, info[FrameHeaderSize:])
+			if !ok {
+				t.Fatalf("parse xing header")
+			}
+			if x.Frames != uint32(len(act)) {
+				t.Errorf("expected %d frames, got %d", len(act), x.Frames)
+			}
+			if x.Bytes != uint32(w.Len()) {
+				t.Errorf("expected %d bytes, got %d", w.Len(), x.Bytes)
+			}
+			l, ok := ParseLAMEHeader(ih, info[FrameHeaderSize:])
+			if !ok {
+				t.Fatalf("parse lame header")
+			}
+			delay, padding := priming*samples, 0
+			if tc.First == 0 {
+				delay += 576
+			}
+			if tc.First+tc.N == n {
+				padding = 1152
+			}
+			delay = min(delay, 0xFFF)
+			if int(l.EncoderDelay) != delay || int(l.PaddingSamples) != padding {
+				t.Errorf("expected gapless (%d, %d), got (%d, %d)", delay, padding, l.EncoderDelay, l.PaddingSamples)
+			}
+			var crc uint16
+			for _, f := range act {
+				crc = crc16LAME(crc, f)
+			}
+			if l.MusicCRC != crc {
+				t.Errorf("expected music crc %04X, got %04X", crc, l.MusicCRC)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		Name       string
+		Start, End time.Duration
+	}{
+		{"empty range", times[10], times[10]},
+		{"past end", r.Time(), r.Time() + time.Second},
+	} {
+		if err := Clip(&bytes.Buffer{}, bytes.NewReader(audio), tc.Start, tc.End); err == nil {
+			t.Errorf("%s: expected error", tc.Name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mp3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// ErrConcat is returned by [Concat] when a stream cannot be concatenated.
+type ErrConcat struct {
+	Stream int   // index of the stream
+	Err    error // from the Reader, or the incompatibility
+}
+
+func (err *ErrConcat) Error() string {
+	return "stream " + strconv.Itoa(err.Stream) + ": " + err.Err.Error()
+}
+
+func (err *ErrConcat) Unwrap() error {
+	return err.Err
+}
+
+// Concat writes the frames from each stream to w. Tags and the Xing, Info, or
+// VBRI frame (which would be incorrect for the combined stream) are not
+// included. All frames must be [FrameHeader.Compatible] with the first one.
+// [ConcatXing] can be used to add a new Xing header for the combined stream.
+func Concat(w io.Writer, streams ...io.Reader) error {
+	var (
+		ref    FrameHeader
+		hasRef bool
+	)
+	for i, stream := range streams {
+		r := NewReader(stream, indexBufferSize)
+		for first := true; r.Next(); first = false {
+			if first && r.IsInfoFrame() {
+				continue
+			}
+			if !hasRef {
+				ref, hasRef = *r.Header(), true
+			} else if !r.Header().Compatible(ref) {
+				return &ErrConcat{Stream: i, Err: errors.New("frame at offset " + strconv.FormatInt(r.Offset()-int64(len(r.Raw())), 10) + " (" + r.Header().String() + ") is not compatible with the first frame (" + ref.String() + ")")}
+			}
+			if _, err := w.Write(r.Raw()); err != nil {
+				return err
+			}
+		}
+		if err := r.Err(); err != nil {
+			return &ErrConcat{Stream: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// ConcatXing is like [Concat], but also writes a Xing frame containing x for
+// the combined stream before the first frame (see [WriteXingHeader]). Since the
+// Xing frame depends on all of the frames, the combined stream is buffered in
+// memory.
+func ConcatXing(w io.Writer, x XingHeader, streams ...io.Reader) error {
+	var buf bytes.Buffer
+	if err := Concat(&buf, streams...); err != nil {
+		return err
+	}
+	return WriteXingHeader(w, bytes.NewReader(buf.Bytes()), int64(buf.Len()), x)
+}
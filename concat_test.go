@@ -0,0 +1,71 @@
+package mp3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	read := func(name string) []byte {
+		buf, err := fs.ReadFile(testdata, name)
+		if err != nil {
+			panic(err)
+		}
+		return buf
+	}
+	a := read("testdata/layer3/he_44khz.mp3")
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeSingleChannel}
+	b := append(testInfoFrame(h, XingHeader{Tag: "Info"}, nil), a...)
+
+	var exp []byte
+	for _, buf := range [][]byte{a, b, a} {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			if !r.IsInfoFrame() {
+				exp = append(exp, r.Raw()...)
+			}
+		}
+	}
+
+	var act bytes.Buffer
+	if err := Concat(&act, bytes.NewReader(a), bytes.NewReader(b), bytes.NewReader(a)); err != nil {
+		t.Fatalf("concat: %v", err)
+	}
+	if !bytes.Equal(act.Bytes(), exp) {
+		t.Errorf("expected %d bytes of frames, got %d", len(exp), act.Len())
+	}
+
+	var cerr *ErrConcat
+	if err := Concat(io.Discard, bytes.NewReader(a), bytes.NewReader(read("testdata/layer3/he_48khz.mp3"))); !errors.As(err, &cerr) || cerr.Stream != 1 {
+		t.Errorf("expected error for incompatible stream 1, got %v", err)
+	}
+	if err := Concat(io.Discard, bytes.NewReader(a), bytes.NewReader(a[:len(a)-10])); !errors.As(err, &cerr) || cerr.Stream != 1 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected truncated frame error for stream 1, got %v", err)
+	}
+
+	act.Reset()
+	if err := ConcatXing(&act, XingHeader{Flags: XingQuality, Quality: 42}, bytes.NewReader(a), bytes.NewReader(b)); err != nil {
+		t.Fatalf("concat with xing header: %v", err)
+	}
+	r := NewReader(bytes.NewReader(act.Bytes()), 16384)
+	if !r.Next() || !r.IsInfoFrame() {
+		t.Fatalf("expected xing frame, got %v", r.Err())
+	}
+	x, ok := ParseXingHeader(*r.Header(), r.Raw()[FrameHeaderSize:])
+	if !ok {
+		t.Fatalf("parse xing header")
+	}
+	var n int
+	for r.Next() {
+		n++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if x.Frames != uint32(n) || x.Quality != 42 {
+		t.Errorf("incorrect xing header %+v for %d frames", x, n)
+	}
+}
@@ -0,0 +1,175 @@
+package mp3
+
+import "strconv"
+
+// ErrChecksumMismatch is returned when the parity-check word of a frame does not
+// match the computed one.
+type ErrChecksumMismatch struct {
+	Offset int64  // of the start of the frame
+	Want   uint16 // computed
+	Got    uint16 // read from the frame
+}
+
+func (err *ErrChecksumMismatch) Error() string {
+	return "checksum mismatch for frame at offset " + strconv.FormatInt(err.Offset, 10) + ": computed " + strconv.FormatUint(uint64(err.Want), 16) + ", got " + strconv.FormatUint(uint64(err.Got), 16)
+}
+
+// ComputeErrorCheck computes the 16 bit parity-check word for a frame with the
+// specified header. The data is the remainder of the frame following the header
+// and the parity-check word. If the length of the protected data cannot be
+// determined, or data is too short, false is returned.
+//
+// The check word is a CRC-16 over the last 16 bits of the header and the bit
+// allocation (Layer I and II), scale factor selection information (Layer II),
+// or side information (Layer III). For Layer II, the length depends on the
+// allocation table, which cannot be determined in free format.
+func ComputeErrorCheck(header FrameHeader, data []byte) (uint16, bool) {
+	var (
+		n  int
+		ok bool
+	)
+	if header.Layer == MPEGLayerII {
+		n, ok = protectedBitsLayerII(header, data)
+	} else {
+		n, ok = protectedBits(header)
+	}
+	if !ok || n > len(data)*8 {
+		return 0, false
+	}
+	var b [FrameHeaderSize]byte
+	header.encode(b[:])
+	crc := crc16(0xFFFF, b[2:], 16)
+	crc = crc16(crc, data, n)
+	return crc, true
+}
+
+// ProtectedRange gets the byte offsets in the raw frame (including the header)
+// of the data following the parity-check word which is covered by it. The last
+// 16 bits of the header are also covered. If the frame is not protected, or the
+// length depends on the contents of the frame rather than only the header (as
+// is the case for Layer II), false is returned.
+func (f FrameHeader) ProtectedRange() (start, end int, ok bool) {
+	if !f.Protection {
+		return 0, 0, false
+	}
+	n, ok := protectedBits(f)
+	if !ok || n%8 != 0 {
+		return 0, 0, false
+	}
+	start = FrameHeaderSize + 2
+	return start, start + n/8, true
+}
+
+// protectedBits returns the number of bits following the check word which are
+// covered by it, if it can be determined from the header alone.
+func protectedBits(f FrameHeader) (int, bool) {
+	var n int
+	switch f.Layer {
+	case MPEGLayerI:
+		// 4 bits of allocation per subband per channel, with subbands at or
+		// above the bound being shared between channels
+		if f.IsMono() {
+			n = 4 * 32
+			break
+		}
+		bound, ok := f.Bound()
+		if !ok {
+			return 0, false
+		}
+		n = 4 * (2*bound + (32 - bound))
+	case MPEGLayerIII:
+		size, ok := f.SideInfoSize()
+		if !ok {
+			return 0, false
+		}
+		n = size * 8
+	default:
+		// Layer II depends on the bit allocation (see protectedBitsLayerII)
+		return 0, false
+	}
+	return n, true
+}
+
+// nbalLayerII contains the number of bits used for the allocation of each
+// subband in the Layer II allocation tables (ISO/IEC 11172-3:1993 table
+// B.2a-d, ISO/IEC 13818-3:1998 table B.1), indexed by sblimit, which uniquely
+// identifies the table for MPEG-1.
+var nbalLayerII = map[int][]uint8{
+	27: {4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 2, 2, 2, 2},          // B.2a
+	30: {4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2}, // B.2b
+	8:  {4, 4, 3, 3, 3, 3, 3, 3},                                                                   // B.2c
+	12: {4, 4, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3},                                                       // B.2d
+}
+
+// nbalLayerIILSF is the Layer II allocation table for the lower sampling
+// frequencies.
+var nbalLayerIILSF = []uint8{4, 4, 4, 4, 3, 3, 3, 3, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+
+// protectedBitsLayerII returns the number of bits following the check word
+// which are covered by it for a Layer II frame, which includes the bit
+// allocation and the scale factor selection information for the subbands with
+// bits allocated. The data is the remainder of the frame following the check
+// word.
+func protectedBitsLayerII(f FrameHeader, data []byte) (int, bool) {
+	sblimit, ok := f.sblimit()
+	if !ok {
+		return 0, false
+	}
+	bound, ok := f.Bound()
+	if !ok {
+		return 0, false
+	}
+	var nbal []uint8
+	if f.ID == MPEGVersion1 {
+		nbal = nbalLayerII[sblimit]
+	} else {
+		nbal = nbalLayerIILSF
+	}
+	if len(nbal) != sblimit {
+		return 0, false
+	}
+	nch := f.ChannelCount()
+
+	var allocated [2][32]bool
+	br := bitReader{b: data}
+	for sb := range sblimit {
+		n := int(nbal[sb])
+		if sb < bound {
+			for ch := range nch {
+				if br.n+n > len(data)*8 {
+					return 0, false
+				}
+				allocated[ch][sb] = br.bits(n) != 0
+			}
+		} else {
+			if br.n+n > len(data)*8 {
+				return 0, false
+			}
+			allocated[0][sb] = br.bits(n) != 0
+			allocated[1][sb] = allocated[0][sb]
+		}
+	}
+	n := br.n
+	for sb := range sblimit {
+		for ch := range nch {
+			if allocated[ch][sb] {
+				n += 2
+			}
+		}
+	}
+	return n, true
+}
+
+// crc16 updates crc with the first n bits of b using the generator polynomial
+// G(X) = X^16 + X^15 + X^2 + 1.
+func crc16(crc uint16, b []byte, n int) uint16 {
+	for i := 0; i < n; i++ {
+		bit := uint16(b[i/8]>>(7-i%8)) & 1
+		if (crc>>15)^bit != 0 {
+			crc = crc<<1 ^ 0x8005
+		} else {
+			crc <<= 1
+		}
+	}
+	return crc
+}
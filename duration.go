@@ -0,0 +1,85 @@
+package mp3
+
+import (
+	"io"
+	"time"
+)
+
+// EstimateDuration gets the duration of the stream in r, which is size bytes
+// long, returning whether the duration is exact.
+//
+// If the first frame contains a Xing or VBRI header with the number of frames,
+// it is used to compute the exact duration of the remaining frames. Otherwise,
+// if the stream appears to be CBR (i.e., the first frame contains an Info
+// header or no VBR header at all, and is not free format), the duration is
+// estimated from the size of the audio data (excluding tags) and the bitrate of
+// the first frame. Otherwise, the entire stream is read to get the exact
+// duration. The frame containing the Xing or VBRI header is not included.
+//
+// If the stream does not contain any valid frames, zero and false are
+// returned.
+func EstimateDuration(r io.ReaderAt, size int64) (time.Duration, bool) {
+	rd := NewReader(io.NewSectionReader(r, 0, size), indexBufferSize)
+	if !rd.Next() {
+		return 0, false
+	}
+	h := *rd.Header()
+	body := rd.Raw()[FrameHeaderSize:]
+	start := rd.Offset() - int64(len(rd.Raw()))
+
+	sampleCount, _ := h.SampleCount()
+	samplingFrequency, _ := h.SamplingFrequency()
+
+	var (
+		cbr  bool
+		info time.Duration // of the info frame, which doesn't contain audio
+	)
+	if x, ok := ParseXingHeader(h, body); ok {
+		if x.Flags&XingFrames != 0 {
+			return samplesDuration(int64(x.Frames)*int64(sampleCount), samplingFrequency), true
+		}
+		cbr = x.IsCBR()
+		start = rd.Offset()
+		info, _ = h.Duration()
+	} else if v, ok := ParseVBRIHeader(h, body); ok {
+		return samplesDuration(int64(v.Frames)*int64(sampleCount), samplingFrequency), true
+	} else {
+		cbr = true
+	}
+
+	if bitrate, _ := h.Bitrate(); cbr && bitrate != 0 {
+		end := size
+		if rd.end != -1 {
+			end = rd.end
+		}
+		if bytes := end - start; bytes > 0 {
+			return time.Duration(float64(bytes) * 8 / float64(bitrate*1000) * float64(time.Second)), false
+		}
+		return 0, false
+	}
+
+	for rd.Next() {
+	}
+	return rd.Time() - info, rd.Err() == nil
+}
+
+// samplesDuration gets the duration of n samples at the specified sampling
+// frequency without overflowing.
+func samplesDuration(n int64, samplingFrequency int) time.Duration {
+	f := int64(samplingFrequency)
+	return time.Duration(n/f)*time.Second + time.Duration(n%f)*time.Second/time.Duration(f)
+}
+
+// CountFrames reads all frames from r using a [Reader] with the specified
+// buffer size, returning the number of frames and the total number of samples
+// (per channel).
+func CountFrames(r io.Reader, buffer int) (frames int, samples int64, err error) {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return 0, 0, err
+	}
+	for rd.Next() {
+		frames++
+	}
+	return frames, rd.SamplePosition(), rd.Err()
+}
@@ -0,0 +1,72 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestEstimateDuration(t *testing.T) {
+	for _, tc := range []struct {
+		Name  string
+		Exact bool
+	}{
+		{"testdata/layer1/fl1.mp1", false},
+		{"testdata/layer2/fl10.mp2", false},
+		{"testdata/layer3/he_free.mp3", true},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			buf, err := fs.ReadFile(testdata, tc.Name)
+			if err != nil {
+				panic(err)
+			}
+			r := NewReader(bytes.NewReader(buf), 16384)
+			for r.Next() {
+			}
+			if err := r.Err(); err != nil {
+				t.Fatalf("read frames: %v", err)
+			}
+			d, exact := EstimateDuration(bytes.NewReader(buf), int64(len(buf)))
+			if exact != tc.Exact {
+				t.Errorf("expected exact=%t, got %t", tc.Exact, exact)
+			}
+			if diff := (d - r.Time()).Abs(); (exact && diff != 0) || diff > mustDuration(r.Header()) {
+				t.Errorf("expected duration %s, got %s", r.Time(), d)
+			}
+		})
+	}
+	for _, vbri := range []bool{false, true} {
+		buf, _ := testVBR(vbri)
+		d, exact := EstimateDuration(bytes.NewReader(buf), int64(len(buf)))
+		if exp := samplesDuration(400*1152, 44100); !exact || d != exp {
+			t.Errorf("vbri=%t: expected exact duration %s, got %s (exact=%t)", vbri, exp, d, exact)
+		}
+	}
+	if d, exact := EstimateDuration(bytes.NewReader(nil), 0); d != 0 || exact {
+		t.Errorf("expected no duration for empty stream, got %s (exact=%t)", d, exact)
+	}
+	if act, exp := samplesDuration(1<<40, 44100), time.Duration(float64(1<<40)/44100*float64(time.Second)); (act - exp).Abs() > time.Microsecond {
+		t.Errorf("expected %s, got %s", exp, act)
+	}
+}
+
+func TestCountFrames(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var frames int
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			frames++
+		}
+		n, samples, err := CountFrames(bytes.NewReader(buf), 16384)
+		if !sameError(err, r.Err()) {
+			t.Errorf("expected error %v, got %v", r.Err(), err)
+		}
+		if n != frames || samples != r.SamplePosition() {
+			t.Errorf("expected %d frames and %d samples, got %d and %d", frames, r.SamplePosition(), n, samples)
+		}
+	})
+	if _, _, err := CountFrames(bytes.NewReader(nil), 0); err == nil {
+		t.Errorf("expected error for invalid buffer size")
+	}
+}
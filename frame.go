@@ -0,0 +1,143 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// Frame is a single frame of an audio stream.
+type Frame struct {
+	// Header is the decoded frame header.
+	Header FrameHeader
+	// Data is the remainder of the frame following the header, including the
+	// parity-check word (if the frame is protected) and the padding slot.
+	Data []byte
+}
+
+// AppendBinary appends the encoded frame, recomputing the parity-check word if
+// the frame is protected. If the length of the data does not match the frame
+// size (except for free format frames), an error is returned.
+func (fr Frame) AppendBinary(dst []byte) ([]byte, error) {
+	if size, ok := fr.Header.FrameSize(); ok {
+		if len(fr.Data) != size-FrameHeaderSize {
+			return dst, errors.New("frame data length " + strconv.Itoa(len(fr.Data)) + " does not match frame size " + strconv.Itoa(size))
+		}
+	} else if fr.Header.BitrateIndex != BitrateIndexFree {
+		return dst, errors.New("cannot determine frame size")
+	}
+	return fr.Header.AppendFrame(dst, fr.Data)
+}
+
+// MarshalBinary encodes the frame (see [Frame.AppendBinary]). It is the inverse
+// of [Frame.UnmarshalBinary], except that the parity-check word is recomputed.
+func (fr Frame) MarshalBinary() ([]byte, error) {
+	return fr.AppendBinary(make([]byte, 0, FrameHeaderSize+len(fr.Data)))
+}
+
+// UnmarshalBinary decodes an entire frame from b, which is copied. If the length
+// of b does not match the frame size (except for free format frames), or the
+// frame is protected but does not contain the parity-check word, an error is
+// returned. The parity-check word is not validated, but it can be retrieved
+// separately from the rest of the data with [Frame.ErrorCheck] and
+// [Frame.Body].
+func (fr *Frame) UnmarshalBinary(b []byte) error {
+	if len(b) < FrameHeaderSize {
+		return errors.New("frame too short (" + strconv.Itoa(len(b)) + " bytes)")
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(b[:FrameHeaderSize]); err != nil {
+		return err
+	}
+	if size, ok := h.FrameSize(); ok {
+		if len(b) != size {
+			return errors.New("frame length " + strconv.Itoa(len(b)) + " does not match frame size " + strconv.Itoa(size))
+		}
+	} else if h.BitrateIndex != BitrateIndexFree {
+		return errors.New("cannot determine frame size")
+	}
+	if h.Protection && len(b) < FrameHeaderSize+2 {
+		return errors.New("frame too short for error check")
+	}
+	fr.Header = h
+	fr.Data = nil
+	if len(b) > FrameHeaderSize {
+		fr.Data = bytes.Clone(b[FrameHeaderSize:])
+	}
+	return nil
+}
+
+// ErrorCheck returns the parity-check word stored in the frame. If the frame is
+// not protected or the data is too short, false is returned.
+func (fr Frame) ErrorCheck() (uint16, bool) {
+	if !fr.Header.Protection || len(fr.Data) < 2 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(fr.Data), true
+}
+
+// Body returns the data following the parity-check word (if the frame is
+// protected), including the padding slot. Unlike [Reader.Data], the padding
+// slot is included so the frame can be reconstructed with
+// [FrameHeader.AppendFrame].
+func (fr Frame) Body() []byte {
+	if fr.Header.Protection {
+		if len(fr.Data) < 2 {
+			return nil
+		}
+		return fr.Data[2:]
+	}
+	return fr.Data
+}
+
+// WriteTo writes the encoded frame to w (see [Frame.AppendBinary]).
+func (fr Frame) WriteTo(w io.Writer) (int64, error) {
+	b, err := fr.AppendBinary(make([]byte, 0, FrameHeaderSize+len(fr.Data)))
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// WriteExact writes the frame to w exactly as it was read, without validating
+// the data length or recomputing the parity-check word. This is useful for
+// copying unchanged frames.
+func (fr Frame) WriteExact(w io.Writer) error {
+	b, _ := fr.Header.AppendBinary(make([]byte, 0, FrameHeaderSize+len(fr.Data)))
+	_, err := w.Write(append(b, fr.Data...))
+	return err
+}
+
+// Frames returns an iterator over the headers and data (see [Frame.Data]) of
+// the frames in b, starting at the first syncword. The data is a subslice of b
+// and is not copied. Iteration stops at the first invalid or incomplete frame.
+// Unlike [Reader], tags are not skipped, and free format frames must all have
+// the same size as the first one (excluding the padding slot).
+func Frames(b []byte) iter.Seq2[FrameHeader, []byte] {
+	return func(yield func(FrameHeader, []byte) bool) {
+		off := Sync(b)
+		if off == -1 {
+			return
+		}
+		var free int // slots in a free format frame, or 0 if not measured yet
+		for len(b)-off >= FrameHeaderSize && IsSyncword(b[off:]) {
+			var f FrameHeader
+			f.decode(b[off:])
+			if checkHeader(f) != nil {
+				return
+			}
+			size, ok := frameSizeAt(f, b[off:], &free)
+			if !ok || len(b)-off < size {
+				return
+			}
+			if !yield(f, b[off+FrameHeaderSize:off+size:off+size]) {
+				return
+			}
+			off += size
+		}
+	}
+}
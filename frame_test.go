@@ -0,0 +1,778 @@
+package mp3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReaderFrame(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var frames []Frame
+		var offsets []int64
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			frames = append(frames, r.Frame())
+			offsets = append(offsets, r.Offset()-int64(len(r.Raw())))
+		}
+		for i, fr := range frames {
+			raw, _ := fr.Header.AppendBinary(nil)
+			raw = append(raw, fr.Data...)
+			if !bytes.Equal(raw, buf[offsets[i]:int(offsets[i])+len(raw)]) {
+				t.Errorf("frame %d: data differs", i+1)
+			}
+		}
+	})
+}
+
+func TestReaderAll(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var n, nh int
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for fr := range r.All() {
+			n++
+			if !bytes.Equal(fr.Data, r.Raw()[FrameHeaderSize:]) {
+				t.Errorf("frame %d: data differs", n)
+			}
+		}
+		err := r.Err()
+
+		r = NewReader(bytes.NewReader(buf), 16384)
+		for range r.Headers() {
+			nh++
+		}
+		if n != nh {
+			t.Errorf("expected %d headers, got %d", n, nh)
+		}
+		if (err == nil) != (r.Err() == nil) {
+			t.Errorf("expected error %v, got %v", err, r.Err())
+		}
+	})
+}
+
+func TestReaderNextN(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+
+	var offsets []int64
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		offsets = append(offsets, r.Offset())
+	}
+
+	r = NewReader(bytes.NewReader(buf), 16384)
+	var total int
+	for {
+		n := r.NextN(7)
+		total += n
+		if n < 7 {
+			break
+		}
+		if act, exp := r.Offset(), offsets[total-1]; act != exp {
+			t.Fatalf("after %d frames: expected offset %d, got %d", total, exp, act)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if total != len(offsets) {
+		t.Errorf("expected %d frames, got %d", len(offsets), total)
+	}
+	if n := r.NextN(7); n != 0 {
+		t.Errorf("expected no frames after end, got %d", n)
+	}
+}
+
+func TestFrameWriteTo(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			fr := r.Frame()
+			var w bytes.Buffer
+			n, err := fr.WriteTo(&w)
+			if err != nil {
+				t.Fatalf("write frame: %v", err)
+			}
+			if n != int64(len(r.Raw())) || w.Len() != len(r.Raw()) {
+				t.Fatalf("expected %d bytes, got %d", len(r.Raw()), n)
+			}
+			act, exp := w.Bytes(), r.Raw()
+			if fr.Header.Protection {
+				crc, _ := ComputeErrorCheck(fr.Header, exp[FrameHeaderSize+2:])
+				if got := binary.BigEndian.Uint16(act[FrameHeaderSize:]); got != crc {
+					t.Errorf("expected recomputed error check %04x, got %04x", crc, got)
+				}
+				act, exp = slices.Concat(act[:FrameHeaderSize], act[FrameHeaderSize+2:]), slices.Concat(exp[:FrameHeaderSize], exp[FrameHeaderSize+2:])
+			}
+			if !bytes.Equal(act, exp) {
+				t.Fatalf("frame differs")
+			}
+		}
+	})
+
+	fr := Frame{Header: FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}}
+	fr.Data = make([]byte, 100)
+	if _, err := fr.AppendBinary(nil); err == nil {
+		t.Errorf("expected error for incorrect data length")
+	}
+	fr.Header.BitrateIndex = BitrateIndexFree
+	if b, err := fr.AppendBinary(nil); err != nil || len(b) != FrameHeaderSize+len(fr.Data) {
+		t.Errorf("expected free format frame to be written, got %d bytes (err=%v)", len(b), err)
+	}
+}
+
+func TestFrameWriteExact(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var w bytes.Buffer
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			w.Reset()
+			if err := r.Frame().WriteExact(&w); err != nil {
+				t.Fatalf("write frame: %v", err)
+			}
+			if !bytes.Equal(w.Bytes(), r.Raw()) {
+				t.Fatalf("frame differs")
+			}
+		}
+	})
+
+	fr := Frame{Header: FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, Protection: true, BitrateIndex: 9, Mode: ModeJointStereo}}
+	fr.Data = []byte{0xAB, 0xCD, 0x01}
+	var w bytes.Buffer
+	if err := fr.WriteExact(&w); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+	if b := w.Bytes(); len(b) != FrameHeaderSize+len(fr.Data) || !bytes.Equal(b[FrameHeaderSize:], fr.Data) {
+		t.Errorf("expected data to be written unchanged, got %x", b)
+	}
+}
+
+func TestReaderResetSize(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	n, _, err := CountFrames(bytes.NewReader(buf), 16384)
+	if err != nil {
+		t.Fatalf("count frames: %v", err)
+	}
+
+	r := NewReader(bytes.NewReader(buf), 64)
+	for r.Next() {
+	}
+	if r.Err() == nil {
+		t.Fatalf("expected error for buffer smaller than a frame")
+	}
+
+	r.ResetSize(bytes.NewReader(buf), 0, 16384)
+	br := r.reader
+	var frames int
+	for r.Next() {
+		frames++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if frames != n {
+		t.Errorf("expected %d frames, got %d", n, frames)
+	}
+
+	r.ResetSize(bytes.NewReader(buf), 0, 16384)
+	if r.reader != br {
+		t.Errorf("expected buffer to be reused")
+	}
+}
+
+func TestReaderBuffered(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	for _, size := range []int{4096, 16384} {
+		r := NewReader(bytes.NewReader(buf), size)
+		if n := r.Size(); n != size {
+			t.Errorf("expected size %d, got %d", size, n)
+		}
+		if n := r.Buffered(); n != 0 {
+			t.Errorf("expected nothing buffered before reading, got %d", n)
+		}
+		for r.Next() {
+			if n := r.Buffered(); n > size || r.Offset()+int64(n) > int64(len(buf)) {
+				t.Errorf("size %d: offset %d: incorrect buffered %d", size, r.Offset(), n)
+			}
+			if _, ok := r.PeekHeader(); ok && r.Buffered() < FrameHeaderSize {
+				t.Errorf("size %d: offset %d: expected next header to be buffered", size, r.Offset())
+			}
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("read frames: %v", err)
+		}
+		if n := r.Buffered(); n != 0 {
+			t.Errorf("size %d: expected nothing buffered at eof, got %d", size, n)
+		}
+	}
+}
+
+func TestNewReaderBuffered(t *testing.T) {
+	var size int
+	for version := range MPEGVersion(4) {
+		for layer := range MPEGLayer(4) {
+			for i := range BitrateIndex(16) {
+				for j := range SamplingFrequencyIndex(4) {
+					f := FrameHeader{ID: version, Layer: layer, BitrateIndex: i, SamplingFrequencyIndex: j, Padding: true}
+					if n, ok := f.FrameSize(); ok {
+						size = max(size, n)
+					}
+				}
+			}
+		}
+	}
+	if size != maxFrameSize {
+		t.Errorf("expected max frame size %d, got %d", size, maxFrameSize)
+	}
+
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	n, _, err := CountFrames(bytes.NewReader(buf), 16384)
+	if err != nil {
+		t.Fatalf("count frames: %v", err)
+	}
+
+	// the reader adopts the buffer, so other data can be read from it first
+	br := bufio.NewReaderSize(io.MultiReader(strings.NewReader("test"), bytes.NewReader(buf)), 4096)
+	if b, _ := br.Peek(4); string(b) != "test" {
+		t.Fatalf("expected test data, got %q", b)
+	}
+	br.Discard(4)
+	r := NewReaderBuffered(br)
+	var frames int
+	for r.Next() {
+		frames++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if frames != n {
+		t.Errorf("expected %d frames, got %d", n, frames)
+	}
+	if r.Offset() != int64(len(buf)) {
+		t.Errorf("expected offset %d, got %d", len(buf), r.Offset())
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic for small buffer")
+			}
+		}()
+		NewReaderBuffered(bufio.NewReaderSize(bytes.NewReader(buf), 1024))
+	}()
+}
+
+func TestFrameUnmarshalBinary(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			var fr Frame
+			if err := fr.UnmarshalBinary(r.Raw()); err != nil {
+				t.Fatalf("unmarshal frame: %v", err)
+			}
+			if exp := r.Frame(); fr.Header != exp.Header || !bytes.Equal(fr.Data, exp.Data) {
+				t.Fatalf("frame differs")
+			}
+			var w bytes.Buffer
+			if err := fr.WriteExact(&w); err != nil || !bytes.Equal(w.Bytes(), r.Raw()) {
+				t.Fatalf("frame does not round-trip (err=%v)", err)
+			}
+			if b, err := fr.MarshalBinary(); err != nil || !bytes.Equal(b, r.Raw()) {
+				t.Fatalf("frame does not marshal to the original (err=%v)", err)
+			}
+
+			crc, ok := fr.ErrorCheck()
+			if exp, expok := r.ErrorCheck(); crc != exp || ok != expok {
+				t.Errorf("expected error check %04x (ok=%t), got %04x (ok=%t)", exp, expok, crc, ok)
+			}
+			start := FrameHeaderSize
+			if fr.Header.Protection {
+				start += 2
+			}
+			if !bytes.Equal(fr.Body(), r.Raw()[start:]) {
+				t.Errorf("incorrect body")
+			}
+			if body, err := fr.Header.AppendFrame(nil, slices.Concat([]byte{0, 0}[:start-FrameHeaderSize], fr.Body())); err != nil || !bytes.Equal(body, r.Raw()) {
+				t.Errorf("frame does not round-trip through the body (err=%v)", err)
+			}
+		}
+	})
+
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	frame, err := h.SilentFrame()
+	if err != nil {
+		panic(err)
+	}
+	var fr Frame
+	for _, b := range [][]byte{
+		nil,
+		frame[:FrameHeaderSize],
+		frame[:len(frame)-1],
+		append(bytes.Clone(frame), 0),
+		slices.Concat([]byte{0, 0, 0, 0}, frame[FrameHeaderSize:]),
+	} {
+		if err := fr.UnmarshalBinary(b); err == nil {
+			t.Errorf("%d bytes: expected error", len(b))
+		}
+	}
+	h.BitrateIndex = BitrateIndexFree
+	h.Protection = true
+	hb, _ := h.MarshalBinary()
+	if err := fr.UnmarshalBinary(slices.Concat(hb, []byte{0})); err == nil {
+		t.Errorf("expected error for missing error check")
+	}
+	if err := fr.UnmarshalBinary(slices.Concat(hb, []byte{0, 0, 1})); err != nil || fr.Header != h || len(fr.Data) != 3 {
+		t.Errorf("expected free format frame to be decoded (err=%v)", err)
+	}
+}
+
+func TestReaderPeekHeader(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_mode.mp3")
+	if err != nil {
+		panic(err)
+	}
+	r := NewReader(bytes.NewReader(buf), 16384)
+	if _, ok := r.PeekHeader(); ok {
+		t.Errorf("expected no header before the first frame")
+	}
+	var n int
+	for r.Next() {
+		n++
+		h, ok := r.PeekHeader()
+		off := r.Offset()
+		if !r.Next() {
+			if ok {
+				t.Errorf("frame %d: expected no header after the last frame", n)
+			}
+			break
+		}
+		n++
+		if !ok || h != *r.Header() {
+			t.Errorf("frame %d: expected peeked header %s, got %s (ok=%t)", n, r.Header(), h, ok)
+		}
+		if act := r.Offset() - int64(len(r.Raw())); act != off {
+			t.Errorf("frame %d: expected frame at %d, got %d", n, off, act)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+}
+
+func TestDistanceToNextSync(t *testing.T) {
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	frame, err := h.SilentFrame()
+	if err != nil {
+		panic(err)
+	}
+	junk := []byte("junk")
+	buf := slices.Concat(frame, frame, junk, frame)
+
+	r := NewReader(bytes.NewReader(buf), 16384)
+	r.SkipErrors(true)
+	if _, ok := r.DistanceToNextSync(); ok {
+		t.Errorf("expected no distance before the first frame")
+	}
+	for i, exp := range []int{len(frame) + len(junk), len(junk), -1} {
+		if !r.Next() {
+			t.Fatalf("read frame %d: %v", i, r.Err())
+		}
+		if d, ok := r.DistanceToNextSync(); ok != (exp != -1) || (ok && d != exp) {
+			t.Errorf("frame %d: expected distance %d, got %d (ok=%t)", i, exp, d, ok)
+		}
+	}
+
+	// the second and third bytes of this header also look like a syncword
+	h = FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerI, BitrateIndex: 14, Mode: ModeStereo}
+	size, _ := h.FrameSize()
+	frame = make([]byte, size)
+	h.encode(frame)
+	if !IsSyncword(frame[1:]) {
+		panic("expected false syncword in header")
+	}
+	r = NewReader(bytes.NewReader(slices.Concat(frame, frame, frame)), 16384)
+	if !r.Next() {
+		t.Fatalf("read frame: %v", r.Err())
+	}
+	if d, ok := r.DistanceToNextSync(); !ok || d != size {
+		t.Errorf("expected distance %d past the false syncword in the header, got %d (ok=%t)", size, d, ok)
+	}
+
+	buf, err = fs.ReadFile(testdata, "testdata/layer3/he_free.mp3")
+	if err != nil {
+		panic(err)
+	}
+	r = NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		d, ok := r.DistanceToNextSync()
+		if !r.Next() {
+			break
+		}
+		if !ok || d > len(r.Raw()) {
+			t.Errorf("expected distance at most %d, got %d (ok=%t)", len(r.Raw()), d, ok)
+		}
+	}
+}
+
+func TestReaderNextContext(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_mode.mp3")
+	if err != nil {
+		panic(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReader(bytes.NewReader(buf), 16384)
+	if !r.NextContext(ctx) || !r.NextContext(ctx) {
+		t.Fatalf("read frames: %v", r.Err())
+	}
+	cancel()
+	if r.NextContext(ctx) {
+		t.Fatalf("expected cancellation")
+	}
+	if err := r.Err(); err != context.Canceled {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if r.Next() {
+		t.Errorf("expected error to be sticky")
+	}
+
+	// the context is checked between buffer fills while synchronizing
+	var fills int
+	ctx, cancel = context.WithCancel(context.Background())
+	r = NewReader(readerFunc(func(p []byte) (int, error) {
+		if fills++; fills == 16 {
+			cancel()
+		} else if fills == 64 {
+			return 0, io.EOF
+		}
+		clear(p)
+		return len(p), nil
+	}), 16384)
+	if r.NextContext(ctx) {
+		t.Fatalf("expected cancellation")
+	}
+	if err := r.Err(); err != context.Canceled {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (fn readerFunc) Read(p []byte) (int, error) {
+	return fn(p)
+}
+
+func TestReaderWriteTo(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var exp []byte
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			exp = append(exp, r.Raw()...)
+		}
+		expErr := r.Err()
+
+		var act bytes.Buffer
+		n, err := NewReader(bytes.NewReader(buf), 16384).WriteTo(&act)
+		if !sameError(err, expErr) {
+			t.Errorf("expected error %v, got %v", expErr, err)
+		}
+		if n != int64(len(exp)) || !bytes.Equal(act.Bytes(), exp) {
+			t.Errorf("expected %d bytes of frames, got %d", len(exp), n)
+		}
+
+		b, err := ExtractAudio(bytes.NewReader(buf), 16384)
+		if !sameError(err, expErr) {
+			t.Errorf("extract: expected error %v, got %v", expErr, err)
+		}
+		if !bytes.Equal(b, exp) {
+			t.Errorf("extract: expected %d bytes of frames, got %d", len(exp), len(b))
+		}
+	})
+	if _, err := ExtractAudio(bytes.NewReader(nil), 0); err == nil {
+		t.Errorf("extract: expected error for invalid buffer size")
+	}
+}
+
+func TestScan(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var exp []Frame
+		var starts []int64
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			exp = append(exp, r.Frame())
+			starts = append(starts, r.Offset()-int64(len(r.Raw())))
+		}
+
+		var n int
+		err := Scan(bytes.NewReader(buf), 16384, func(f FrameHeader, raw []byte, offset int64) error {
+			if f != exp[n].Header || !bytes.Equal(raw[FrameHeaderSize:], exp[n].Data) || offset != starts[n] {
+				t.Errorf("frame %d: incorrect frame", n)
+			}
+			n++
+			return nil
+		})
+		if !sameError(err, r.Err()) {
+			t.Errorf("expected error %v, got %v", r.Err(), err)
+		}
+		if n != len(exp) {
+			t.Errorf("expected %d frames, got %d", len(exp), n)
+		}
+
+		if len(exp) > 2 {
+			stop := errors.New("stop")
+			n = 0
+			err = Scan(bytes.NewReader(buf), 16384, func(f FrameHeader, raw []byte, offset int64) error {
+				if n++; n == 2 {
+					return stop
+				}
+				return nil
+			})
+			var serr *ErrScan
+			if !errors.As(err, &serr) || serr.Offset != starts[1] || !errors.Is(err, stop) {
+				t.Errorf("expected scan error for the second frame, got %v", err)
+			}
+		}
+	})
+}
+
+func TestScanHeaders(t *testing.T) {
+	type frame struct {
+		Header FrameHeader
+		Offset int64
+	}
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var exp, act []frame
+		experr := Scan(bytes.NewReader(buf), 16384, func(f FrameHeader, raw []byte, offset int64) error {
+			exp = append(exp, frame{f, offset})
+			return nil
+		})
+		err := ScanHeaders(bytes.NewReader(buf), func(f FrameHeader, offset int64) error {
+			act = append(act, frame{f, offset})
+			return nil
+		})
+		if (err == nil) != (experr == nil) {
+			t.Errorf("expected error %v, got %v", experr, err)
+		}
+		if !slices.Equal(act, exp) {
+			t.Errorf("expected %d frames, got %d", len(exp), len(act))
+		}
+
+		if len(exp) > 2 {
+			stop := errors.New("stop")
+			var n int
+			err = ScanHeaders(bytes.NewReader(buf), func(f FrameHeader, offset int64) error {
+				if n++; n == 2 {
+					return stop
+				}
+				return nil
+			})
+			var serr *ErrScan
+			if !errors.As(err, &serr) || serr.Offset != exp[1].Offset || !errors.Is(err, stop) {
+				t.Errorf("expected scan error for the second frame, got %v", err)
+			}
+		}
+	})
+	for _, buf := range [][]byte{nil, []byte("junk")} {
+		if err := ScanHeaders(bytes.NewReader(buf), func(FrameHeader, int64) error { return nil }); err != ErrUnsynchronized {
+			t.Errorf("%q: expected error %v, got %v", buf, ErrUnsynchronized, err)
+		}
+	}
+}
+
+func TestFrames(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var exp []Frame
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			exp = append(exp, r.Frame())
+		}
+		_, tagged := r.ID3v1()
+		tagged = tagged || r.ID3v2Size() != 0
+		if _, ok := r.APESize(); ok {
+			tagged = true
+		}
+
+		var n int
+		for h, data := range Frames(buf) {
+			if n >= len(exp) {
+				if !tagged {
+					t.Errorf("frame %d: unexpected frame", n)
+				}
+				break
+			}
+			if h != exp[n].Header || !bytes.Equal(data, exp[n].Data) {
+				t.Errorf("frame %d: incorrect frame", n)
+			}
+			if cap(data) != len(data) {
+				t.Errorf("frame %d: expected data to be capped", n)
+			}
+			n++
+		}
+		if r.Err() == nil && !tagged && n != len(exp) {
+			t.Errorf("expected %d frames, got %d", len(exp), n)
+		}
+	})
+
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	n, _, err := CountFrames(bytes.NewReader(buf), 16384)
+	if err != nil {
+		t.Fatalf("count frames: %v", err)
+	}
+	for _, tc := range []struct {
+		Name   string
+		Buf    []byte
+		Frames int
+	}{
+		{"empty", nil, 0},
+		{"junk", []byte("junk"), 0},
+		{"leading junk", slices.Concat([]byte("junk"), buf), n},
+		{"truncated", buf[:len(buf)-1], n - 1},
+		{"trailing junk", slices.Concat(buf, []byte{0xFF, 0xFF, 0xFF, 0xFF}), n},
+	} {
+		var act int
+		for range Frames(tc.Buf) {
+			act++
+		}
+		if act != tc.Frames {
+			t.Errorf("%s: expected %d frames, got %d", tc.Name, tc.Frames, act)
+		}
+	}
+	if a := testing.AllocsPerRun(10, func() {
+		for h, data := range Frames(buf) {
+			_, _ = h, data
+		}
+	}); a != 0 {
+		t.Errorf("expected no allocations, got %f", a)
+	}
+}
+
+func TestVerifyContiguous(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var ends []int64
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		ends = append(ends, r.Offset())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+
+	if n, err := VerifyContiguous(bytes.NewReader(slices.Concat([]byte("junk"), buf)), 16384); err != nil || n != len(ends) {
+		t.Errorf("expected %d contiguous frames, got %d (err=%v)", len(ends), n, err)
+	}
+
+	for _, tc := range []struct {
+		Name   string
+		Buf    []byte
+		Frames int
+		Offset int64
+		Err    error
+	}{
+		{"injected", slices.Concat(buf[:ends[9]], []byte("junk"), buf[ends[9]:]), 10, ends[9], ErrUnsynchronized},
+		{"reserved layer", slices.Concat(buf[:ends[9]], []byte{0xFF, 0xF9, 0x00, 0x00}, buf[ends[9]:]), 10, ends[9], ErrReservedLayer},
+		{"bad bitrate", slices.Concat(buf[:ends[9]], []byte{0xFF, 0xFB, 0xF0, 0x00}, buf[ends[9]:]), 10, ends[9], ErrInvalidBitrate},
+		{"truncated", buf[:len(buf)-10], len(ends) - 1, ends[len(ends)-2], io.ErrUnexpectedEOF},
+	} {
+		n, err := VerifyContiguous(bytes.NewReader(tc.Buf), 16384)
+		var cerr *ErrNotContiguous
+		if !errors.As(err, &cerr) || cerr.Offset != tc.Offset || !errors.Is(err, tc.Err) {
+			t.Errorf("%s: expected error at offset %d, got %v", tc.Name, tc.Offset, err)
+		}
+		if n != tc.Frames {
+			t.Errorf("%s: expected %d frames, got %d", tc.Name, tc.Frames, n)
+		}
+	}
+
+	// i/o errors from the source are not framing errors
+	fail := errors.New("fail")
+	src := io.MultiReader(bytes.NewReader(buf[:ends[len(ends)-10]+100]), iotest.ErrReader(fail))
+	if n, err := VerifyContiguous(src, 16384); err != fail || n == 0 {
+		t.Errorf("expected source error after reading frames, got %v after %d frames", err, n)
+	}
+
+	if _, err := VerifyContiguous(bytes.NewReader([]byte("junk")), 16384); err == nil || errors.As(err, new(*ErrNotContiguous)) {
+		t.Errorf("expected synchronization error without any frames, got %v", err)
+	}
+}
+
+func TestLimitFrames(t *testing.T) {
+	audio, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(audio[:FrameHeaderSize]); err != nil {
+		panic(err)
+	}
+	h.BitrateIndex = 9
+	n, _, err := CountFrames(bytes.NewReader(audio), 16384)
+	if err != nil {
+		t.Fatalf("count frames: %v", err)
+	}
+	junk := bytes.Repeat([]byte{0xFF, 0xFB, 0xF0, 0x00}, 100)
+	buf := slices.Concat(testInfoFrame(h, XingHeader{Tag: "Xing", Flags: XingFrames, Frames: uint32(n)}, nil), audio, junk)
+
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+	}
+	if r.Err() == nil {
+		t.Fatalf("expected error for trailing junk without a limit")
+	}
+
+	r = NewReader(bytes.NewReader(buf), 16384)
+	if !r.Next() {
+		t.Fatalf("read info frame: %v", r.Err())
+	}
+	x, ok := ParseXingHeader(*r.Header(), r.Raw()[FrameHeaderSize:])
+	if !ok {
+		t.Fatalf("parse xing header")
+	}
+	r.LimitFrames(int(x.Frames))
+	var frames int
+	for r.Next() {
+		frames++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if frames != n {
+		t.Errorf("expected %d frames, got %d", n, frames)
+	}
+	if exp := int64(len(buf) - len(junk)); r.Offset() != exp {
+		t.Errorf("expected offset %d, got %d", exp, r.Offset())
+	}
+
+	r.Reset(bytes.NewReader(audio), 0)
+	r.LimitFrames(3)
+	if act := r.NextN(n); act != 3 || r.Err() != nil {
+		t.Errorf("expected 3 frames, got %d (err=%v)", act, r.Err())
+	}
+	r.Reset(bytes.NewReader(audio), 0)
+	if act := r.NextN(n); act != n {
+		t.Errorf("expected limit to be removed by reset, got %d frames", act)
+	}
+}
@@ -0,0 +1,152 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// TrimGapless writes the stream in r to w with the Xing header and LAME
+// extension in the first frame updated to match the audio frames, so the
+// gapless playback information (see [LAMEHeader.Gapless]) remains correct after
+// streams are concatenated as-is or truncated. Since partial frames cannot be
+// removed without decoding, the audio frames themselves are not changed.
+//
+// The number of frames, the number of bytes, and the table of contents (if
+// present) in the Xing header, and the music length and CRC in the LAME
+// extension are computed from the stream. The encoder delay is kept from the
+// first frame. If other frames containing a Xing, Info, or VBRI header are
+// found (i.e., the start of another stream), they are removed, and the padding
+// is taken from the last one (or set to zero if it does not have a LAME
+// extension). If the last stream has fewer frames than its Xing header
+// declares, it was truncated, so the padding is set to zero.
+func TrimGapless(w io.Writer, r io.ReadSeeker) error {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	ra := seekReaderAt{r}
+	rd := NewReader(io.NewSectionReader(ra, 0, size), indexBufferSize)
+	if !rd.Next() {
+		if err := rd.Err(); err != nil {
+			return err
+		}
+		return errors.New("no frames")
+	}
+	h := *rd.Header()
+	info := bytes.Clone(rd.Raw())
+	start := rd.Offset() - int64(len(info))
+	x, ok := ParseXingHeader(h, info[FrameHeaderSize:])
+	if !ok {
+		return errors.New("first frame does not contain a xing header")
+	}
+	l, ok := ParseLAMEHeader(h, info[FrameHeaderSize:])
+	if !ok {
+		return errors.New("first frame does not contain a lame extension")
+	}
+
+	var (
+		ix       Index
+		segments [][2]int64 // of contiguous audio frames to copy
+		audio    int64      // size of the audio frames
+		crc      uint16     // of the audio frames
+		padding  = l.PaddingSamples
+		declared = -1 // number of frames in the current stream according to its xing header, if any
+		frames   int  // in the current stream
+	)
+	if x.Flags&XingFrames != 0 {
+		declared = int(x.Frames)
+	}
+	for rd.Next() {
+		raw := rd.Raw()
+		off := rd.Offset() - int64(len(raw))
+		if rd.IsInfoFrame() {
+			padding, declared, frames = 0, -1, 0
+			if x, ok := ParseXingHeader(*rd.Header(), raw[FrameHeaderSize:]); ok && x.Flags&XingFrames != 0 {
+				declared = int(x.Frames)
+			}
+			if l, ok := ParseLAMEHeader(*rd.Header(), raw[FrameHeaderSize:]); ok {
+				padding = l.PaddingSamples
+			}
+			continue
+		}
+		if err := ix.add(int64(len(info))+audio, len(raw), rd.Header()); err != nil {
+			return err
+		}
+		if n := len(segments); n != 0 && segments[n-1][1] == off {
+			segments[n-1][1] += int64(len(raw))
+		} else {
+			segments = append(segments, [2]int64{off, off + int64(len(raw))})
+		}
+		audio += int64(len(raw))
+		crc = crc16LAME(crc, raw)
+		frames++
+	}
+	if err := rd.Err(); err != nil {
+		return err
+	}
+	if declared != -1 && frames < declared {
+		padding = 0
+	}
+
+	frame, err := updateInfoFrame(h, info, &ix, int64(len(info))+audio, crc, int(l.EncoderDelay), int(padding))
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, io.NewSectionReader(ra, 0, start)); err != nil {
+		return err
+	}
+	if _, err := w.Write(frame); err != nil {
+		return err
+	}
+	for _, s := range segments {
+		if _, err := io.Copy(w, io.NewSectionReader(ra, s[0], s[1]-s[0])); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(ra, rd.Offset(), size-rd.Offset())); err != nil {
+		return err
+	}
+	return nil
+}
+
+// updateInfoFrame updates the Xing header and LAME extension (if present) in
+// the raw info frame for a stream of the specified size, starting with the info
+// frame, followed by the indexed frames (with offsets relative to the start of
+// the info frame), and returns the re-encoded frame. The delay and padding are
+// clamped to the range of the LAME extension.
+func updateInfoFrame(h FrameHeader, info []byte, ix *Index, size int64, crc uint16, delay, padding int) ([]byte, error) {
+	body := info[FrameHeaderSize:]
+	x, xend, ok := parseXingHeader(h, body)
+	if !ok {
+		return nil, errors.New("frame does not contain a xing header")
+	}
+	_, lame := ParseLAMEHeader(h, body)
+
+	x.Frames = uint32(len(ix.sizes))
+	x.Bytes = uint32(size)
+	if x.Flags&XingTOC != 0 {
+		x.TOC = ix.toc(0, size)
+	}
+	xb, err := x.AppendBinary(nil)
+	if err != nil {
+		return nil, err
+	}
+	copy(body[xend-len(xb):], xb)
+
+	if lame {
+		delay, padding = min(max(delay, 0), 0xFFF), min(max(padding, 0), 0xFFF)
+		b := body[xend : xend+lameHeaderSize]
+		b[21] = byte(delay >> 4)
+		b[22] = byte(delay<<4) | byte(padding>>8)
+		b[23] = byte(padding)
+		binary.BigEndian.PutUint32(b[28:], x.Bytes)
+		binary.BigEndian.PutUint16(b[32:], crc)
+		var hdr [FrameHeaderSize]byte
+		h.encode(hdr[:])
+		binary.BigEndian.PutUint16(b[34:], crc16LAME(crc16LAME(0, hdr[:]), body[:xend+lameHeaderSize-2]))
+	}
+	return h.AppendFrame(nil, body)
+}
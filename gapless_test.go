@@ -0,0 +1,108 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+func TestTrimGapless(t *testing.T) {
+	audio, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(audio[:FrameHeaderSize]); err != nil {
+		panic(err)
+	}
+	h.BitrateIndex = 9
+	var ends []int64
+	r := NewReader(bytes.NewReader(audio), 16384)
+	for r.Next() {
+		ends = append(ends, r.Offset())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	n := len(ends)
+
+	// a stream with n frames and the specified padding
+	stream := func(padding int) []byte {
+		lame := bytes.Clone(testLAME)
+		lame[22] = lame[22]&0xF0 | byte(padding>>8)
+		lame[23] = byte(padding)
+		x := XingHeader{Tag: "Xing", Flags: XingFrames | XingBytes | XingTOC, Frames: uint32(n)}
+		return slices.Concat(testInfoFrame(h, x, lame), audio)
+	}
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+
+	for _, tc := range []struct {
+		Name    string
+		Buf     []byte
+		Frames  int
+		Padding int
+	}{
+		{"single", stream(1000), n, 1000},
+		{"concat", slices.Concat(stream(1000), stream(100)), 2 * n, 100},
+		{"concat tag", slices.Concat(stream(1000), stream(100), tag), 2 * n, 100},
+		{"truncated", stream(1000)[:len(stream(1000))-len(audio)+int(ends[n-3])], n - 2, 0},
+		{"concat truncated", slices.Concat(stream(1000), stream(100)[:len(stream(100))-len(audio)+int(ends[n-3])]), 2*n - 2, 0},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			var w bytes.Buffer
+			if err := TrimGapless(&w, bytes.NewReader(tc.Buf)); err != nil {
+				t.Fatalf("trim gapless: %v", err)
+			}
+			out := w.Bytes()
+
+			r := NewReader(bytes.NewReader(out), 16384)
+			if !r.Next() {
+				t.Fatalf("read info frame: %v", r.Err())
+			}
+			info := bytes.Clone(r.Raw())
+			x, ok := ParseXingHeader(*r.Header(), info[FrameHeaderSize:])
+			if !ok {
+				t.Fatalf("parse xing header")
+			}
+			l, ok := ParseLAMEHeader(*r.Header(), info[FrameHeaderSize:])
+			if !ok {
+				t.Fatalf("parse lame header")
+			}
+			var (
+				frames int
+				crc    uint16
+			)
+			for r.Next() {
+				if r.IsInfoFrame() {
+					t.Errorf("unexpected info frame at offset %d", r.Offset()-int64(len(r.Raw())))
+				}
+				frames++
+				crc = crc16LAME(crc, r.Raw())
+			}
+			if err := r.Err(); err != nil {
+				t.Fatalf("read frames: %v", err)
+			}
+			if frames != tc.Frames || int(x.Frames) != tc.Frames {
+				t.Errorf("expected %d frames, got %d (xing %d)", tc.Frames, frames, x.Frames)
+			}
+			if int64(x.Bytes) != r.Offset() || int64(l.MusicLength) != r.Offset() {
+				t.Errorf("expected %d bytes, got %d (lame %d)", r.Offset(), x.Bytes, l.MusicLength)
+			}
+			if delay, padding := l.Gapless(); delay != 576 || padding != tc.Padding {
+				t.Errorf("expected gapless (576, %d), got (%d, %d)", tc.Padding, delay, padding)
+			}
+			if l.MusicCRC != crc {
+				t.Errorf("expected music crc %04x, got %04x", crc, l.MusicCRC)
+			}
+			if _, ok := r.ID3v1(); ok != bytes.HasSuffix(tc.Buf, tag) {
+				t.Errorf("expected trailing tags to be copied")
+			}
+		})
+	}
+
+	if err := TrimGapless(&bytes.Buffer{}, bytes.NewReader(audio)); err == nil {
+		t.Errorf("expected error without a lame extension")
+	}
+}
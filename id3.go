@@ -0,0 +1,86 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// id3v2HeaderSize is the size of the ID3v2 header and footer.
+const id3v2HeaderSize = 10
+
+// id3v2Size gets the total size (including the header and footer) of the ID3v2
+// tag at the start of b, which must contain at least the header. If b does not
+// start with a valid ID3v2 header, false is returned.
+func id3v2Size(b []byte) (int64, bool) {
+	if len(b) < id3v2HeaderSize || string(b[:3]) != "ID3" || b[3] == 0xFF || b[4] == 0xFF {
+		return 0, false
+	}
+	var n int64
+	for _, c := range b[6:10] {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | int64(c)
+	}
+	n += id3v2HeaderSize
+	if b[5]&0x10 != 0 {
+		n += id3v2HeaderSize // footer
+	}
+	return n, true
+}
+
+// id3v1Size is the size of an ID3v1 tag.
+const id3v1Size = 128
+
+// isID3v1 checks if b is an ID3v1 tag.
+func isID3v1(b []byte) bool {
+	return len(b) == id3v1Size && string(b[:3]) == "TAG"
+}
+
+// apeFooterSize is the size of an APEv2 header or footer.
+const apeFooterSize = 32
+
+// apeSize gets the total size (including the header and footer) of the APEv2
+// tag ending with the specified footer. If it is not a valid APEv2 footer, false
+// is returned.
+func apeSize(b []byte) (int64, bool) {
+	if len(b) != apeFooterSize || string(b[:8]) != "APETAGEX" {
+		return 0, false
+	}
+	size := int64(binary.LittleEndian.Uint32(b[12:]))
+	if size < apeFooterSize {
+		return 0, false
+	}
+	if flags := binary.LittleEndian.Uint32(b[20:]); flags&(1<<31) != 0 {
+		size += apeFooterSize // header
+	}
+	return size, true
+}
+
+// readTailTags checks for an ID3v1 tag and an APEv2 tag (before the ID3v1 tag)
+// at the end of r, which is size bytes long, returning the offset of the start
+// of the tags.
+func readTailTags(r io.ReaderAt, size int64) (end int64, id3v1 []byte, ape int64, err error) {
+	end = size
+	if end >= id3v1Size {
+		tag := make([]byte, id3v1Size)
+		if _, err := r.ReadAt(tag, end-id3v1Size); err != nil {
+			return end, nil, 0, err
+		}
+		if isID3v1(tag) {
+			id3v1 = tag
+			end -= id3v1Size
+		}
+	}
+	if end >= apeFooterSize {
+		footer := make([]byte, apeFooterSize)
+		if _, err := r.ReadAt(footer, end-apeFooterSize); err != nil {
+			return end, id3v1, 0, err
+		}
+		if size, ok := apeSize(footer); ok && size <= end {
+			ape = size
+			end -= size
+		}
+	}
+	return end, id3v1, ape, nil
+}
@@ -0,0 +1,115 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+)
+
+func TestReaderID3v2(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+
+	tag := make([]byte, 20000)
+	copy(tag, "ID3\x04\x00\x00")
+	tag[6], tag[7], tag[8], tag[9] = 0, byte((len(tag)-10)>>14&0x7F), byte((len(tag)-10)>>7&0x7F), byte((len(tag)-10)&0x7F)
+	for i := 10; i < len(tag); i++ {
+		tag[i] = 0xFF // will cause false syncs if not skipped correctly
+	}
+
+	r := NewReader(bytes.NewReader(append(tag, buf...)), 4096)
+	if !r.Next() {
+		t.Fatalf("read first frame: %v", r.Err())
+	}
+	if act, exp := r.ID3v2Size(), int64(len(tag)); act != exp {
+		t.Errorf("expected id3v2 size %d, got %d", exp, act)
+	}
+	if act, exp := r.Offset()-int64(len(r.Raw())), int64(len(tag)); act != exp {
+		t.Errorf("expected first frame at %d, got %d", exp, act)
+	}
+}
+
+func TestReaderID3v1(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+
+	var n int
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		n++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+
+	tag := make([]byte, 128)
+	copy(tag, "TAG\xFF\xFB\x90\x00") // with a syncword to ensure it isn't treated as a frame
+	buf = append(bytes.Clone(buf), tag...)
+
+	var nt int
+	r = NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		nt++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if nt != n {
+		t.Errorf("expected %d frames, got %d", n, nt)
+	}
+	if act, ok := r.ID3v1(); !ok || !bytes.Equal(act, tag) {
+		t.Errorf("expected id3v1 tag to be returned")
+	}
+}
+
+func TestReaderAPE(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+
+	var n int
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		n++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+
+	ape := make([]byte, 32+16+32)
+	copy(ape, "APETAGEX\xD0\x07\x00\x00\x30\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\xA0")
+	copy(ape[32:], "\xFF\xFB\x90\x00\xFF\xFB\x90\x00") // syncwords to ensure it isn't treated as a frame
+	copy(ape[48:], "APETAGEX\xD0\x07\x00\x00\x30\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x80")
+
+	for _, id3v1 := range []bool{false, true} {
+		b := append(bytes.Clone(buf), ape...)
+		if id3v1 {
+			tag := make([]byte, 128)
+			copy(tag, "TAG")
+			b = append(b, tag...)
+		}
+
+		var nt int
+		r = NewReader(bytes.NewReader(b), 16384)
+		for r.Next() {
+			nt++
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("read frames: %v", err)
+		}
+		if nt != n {
+			t.Errorf("expected %d frames, got %d", n, nt)
+		}
+		if act, ok := r.APESize(); !ok || act != int64(len(ape)) {
+			t.Errorf("expected ape size %d, got %d", len(ape), act)
+		}
+		if _, ok := r.ID3v1(); ok != id3v1 {
+			t.Errorf("expected id3v1 %t, got %t", id3v1, ok)
+		}
+	}
+}
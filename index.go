@@ -0,0 +1,222 @@
+package mp3
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Index contains the offset, time, and sample position of every frame in a
+// stream. To keep it small, only the size and format of each frame is stored,
+// with the absolute position stored every indexInterval frames.
+type Index struct {
+	sizes    []uint16      // of each frame
+	kinds    []uint8       // index into formats for each frame
+	formats  []indexFormat // distinct frame durations and sample counts
+	marks    []indexMark   // for every indexInterval frames
+	duration time.Duration
+	samples  int64
+}
+
+type indexFormat struct {
+	duration time.Duration
+	samples  int
+}
+
+type indexMark struct {
+	offset  int64
+	time    time.Duration
+	samples int64
+}
+
+// indexInterval is the number of frames between absolute positions in an
+// [Index].
+const indexInterval = 64
+
+// indexBufferSize is the buffer size used by [BuildIndex], which is enough to
+// fit two frames of any valid non-free-format stream.
+const indexBufferSize = 1 << 14
+
+// BuildIndex reads all frames from r and indexes them. If size is negative, the
+// stream is read until EOF, and trailing tags will not be detected.
+func BuildIndex(r io.ReaderAt, size int64) (*Index, error) {
+	var src io.Reader
+	if size < 0 {
+		src = struct{ io.Reader }{io.NewSectionReader(r, 0, math.MaxInt64)}
+	} else {
+		src = io.NewSectionReader(r, 0, size)
+	}
+	rd := NewReader(src, indexBufferSize)
+	ix := new(Index)
+	for rd.Next() {
+		if err := ix.add(rd.Offset()-int64(len(rd.Raw())), len(rd.Raw()), rd.Header()); err != nil {
+			return nil, err
+		}
+	}
+	if err := rd.Err(); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// add adds a frame to the index. The frames must be contiguous.
+func (ix *Index) add(offset int64, size int, h *FrameHeader) error {
+	if size > math.MaxUint16 {
+		return errors.New("frame at offset " + strconv.FormatInt(offset, 10) + " is too large to index")
+	}
+	var f indexFormat
+	f.duration, _ = h.Duration()
+	f.samples, _ = h.SampleCount()
+	i := 0
+	for i < len(ix.formats) && ix.formats[i] != f {
+		i++
+	}
+	if i == len(ix.formats) {
+		ix.formats = append(ix.formats, f)
+	}
+	if len(ix.sizes)%indexInterval == 0 {
+		ix.marks = append(ix.marks, indexMark{
+			offset:  offset,
+			time:    ix.duration,
+			samples: ix.samples,
+		})
+	}
+	ix.sizes = append(ix.sizes, uint16(size))
+	ix.kinds = append(ix.kinds, uint8(i))
+	ix.duration += f.duration
+	ix.samples += int64(f.samples)
+	return nil
+}
+
+// Duration returns the total duration of all indexed frames.
+func (ix *Index) Duration() time.Duration {
+	return ix.duration
+}
+
+// FrameAt finds the frame containing t, returning its offset and frame number.
+// If t is negative or not before the end of the last frame, false is returned.
+func (ix *Index) FrameAt(t time.Duration) (byteOffset int64, frame int, ok bool) {
+	byteOffset, _, frame, ok = ix.frameAt(t)
+	return
+}
+
+// frameAt is like FrameAt, but also returns the start time of the frame.
+func (ix *Index) frameAt(t time.Duration) (byteOffset int64, start time.Duration, frame int, ok bool) {
+	if t < 0 || t >= ix.duration {
+		return 0, 0, 0, false
+	}
+	m := sort.Search(len(ix.marks), func(i int) bool {
+		return ix.marks[i].time > t
+	}) - 1
+	mark := ix.marks[m]
+	frame, byteOffset, start = m*indexInterval, mark.offset, mark.time
+	for ; ; frame++ {
+		end := start + ix.formats[ix.kinds[frame]].duration
+		if end > t {
+			return byteOffset, start, frame, true
+		}
+		byteOffset += int64(ix.sizes[frame])
+		start = end
+	}
+}
+
+// OffsetAt gets the byte offset corresponding to t, interpolated linearly
+// within the frame containing it. If t is negative or not before the end of
+// the last frame, false is returned.
+func (ix *Index) OffsetAt(t time.Duration) (int64, bool) {
+	byteOffset, start, frame, ok := ix.frameAt(t)
+	if !ok {
+		return 0, false
+	}
+	if d := ix.formats[ix.kinds[frame]].duration; d > 0 {
+		byteOffset += int64(ix.sizes[frame]) * int64(t-start) / int64(d)
+	}
+	return byteOffset, true
+}
+
+// TimeAt gets the time corresponding to byteOffset, interpolated linearly
+// within the frame containing it. If byteOffset is not within an indexed frame,
+// false is returned.
+func (ix *Index) TimeAt(byteOffset int64) (time.Duration, bool) {
+	m := sort.Search(len(ix.marks), func(i int) bool {
+		return ix.marks[i].offset > byteOffset
+	}) - 1
+	if m < 0 {
+		return 0, false
+	}
+	mark := ix.marks[m]
+	off, t := mark.offset, mark.time
+	for frame := m * indexInterval; frame < len(ix.sizes); frame++ {
+		size, d := int64(ix.sizes[frame]), ix.formats[ix.kinds[frame]].duration
+		if off+size > byteOffset {
+			return t + time.Duration(int64(d)*(byteOffset-off)/size), true
+		}
+		off += size
+		t += d
+	}
+	return 0, false
+}
+
+// BuildTOC creates a Xing table of contents (see [XingHeader.TOC]) for the
+// indexed frames, with offsets relative to the start of the first frame, in
+// units of 1/256 of the total size of the frames.
+func (ix *Index) BuildTOC() [100]byte {
+	if len(ix.marks) == 0 {
+		return [100]byte{}
+	}
+	base := ix.marks[0].offset
+	return ix.toc(base, ix.end()-base)
+}
+
+// end returns the offset of the end of the last indexed frame.
+func (ix *Index) end() int64 {
+	if len(ix.marks) == 0 {
+		return 0
+	}
+	m := len(ix.marks) - 1
+	off := ix.marks[m].offset
+	for _, size := range ix.sizes[m*indexInterval:] {
+		off += int64(size)
+	}
+	return off
+}
+
+// toc creates a Xing table of contents for the indexed frames in a stream of the
+// specified size, with the offsets relative to base.
+func (ix *Index) toc(base, size int64) [100]byte {
+	var toc [100]byte
+	if size <= 0 || ix.duration <= 0 {
+		return toc
+	}
+	for i := range toc {
+		off, _, _ := ix.FrameAt(time.Duration(float64(ix.duration) * float64(i) / 100))
+		toc[i] = byte(min(max((off-base)*256/size, 0), 255))
+	}
+	return toc
+}
+
+// FrameAlignedRange gets the byte range [byteStart, byteEnd) of the frames
+// containing the time range [start, end). If end is past the end of the last
+// frame, the range ends at the end of the last frame. If start is negative or
+// not before the end of the last frame, or end is not after start, false is
+// returned.
+//
+// Note that for Layer III, the first frames of the range may depend on main
+// data from the bit reservoir in the preceding frames.
+func FrameAlignedRange(ix *Index, start, end time.Duration) (byteStart, byteEnd int64, ok bool) {
+	if end <= start {
+		return 0, 0, false
+	}
+	byteStart, _, ok = ix.FrameAt(start)
+	if !ok {
+		return 0, 0, false
+	}
+	last, frame, ok := ix.FrameAt(min(end, ix.duration) - 1)
+	if !ok {
+		return 0, 0, false
+	}
+	return byteStart, last + int64(ix.sizes[frame]), true
+}
@@ -0,0 +1,147 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestIndex(t *testing.T) {
+	for _, name := range []string{
+		"testdata/layer1/fl1.mp1",
+		"testdata/layer2/fl10.mp2",
+		"testdata/layer3/he_44khz.mp3",
+		"testdata/layer3/he_free.mp3",
+		"testdata/mpeg2/test01.mpg",
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf, err := fs.ReadFile(testdata, name)
+			if err != nil {
+				panic(err)
+			}
+
+			var (
+				starts []int64
+				times  []time.Duration
+			)
+			r := NewReader(bytes.NewReader(buf), 16384)
+			for r.Next() {
+				starts = append(starts, r.Offset()-int64(len(r.Raw())))
+				times = append(times, r.Time()-mustDuration(r.Header()))
+			}
+			if err := r.Err(); err != nil {
+				t.Fatalf("read frames: %v", err)
+			}
+
+			for _, size := range []int64{int64(len(buf)), -1} {
+				ix, err := BuildIndex(bytes.NewReader(buf), size)
+				if err != nil {
+					t.Fatalf("build index: %v", err)
+				}
+				if act, exp := ix.Duration(), r.Time(); act != exp {
+					t.Errorf("expected duration %s, got %s", exp, act)
+				}
+				for i := range starts {
+					end := ix.Duration()
+					if i+1 < len(times) {
+						end = times[i+1]
+					}
+					for _, d := range []time.Duration{times[i], end - 1} {
+						off, frame, ok := ix.FrameAt(d)
+						if !ok || off != starts[i] || frame != i {
+							t.Fatalf("frame at %s: expected frame %d at %d, got frame %d at %d (ok=%t)", d, i, starts[i], frame, off, ok)
+						}
+					}
+				}
+				for _, d := range []time.Duration{-1, ix.Duration(), ix.Duration() + time.Second} {
+					if _, _, ok := ix.FrameAt(d); ok {
+						t.Errorf("frame at %s: expected no frame", d)
+					}
+					if _, ok := ix.OffsetAt(d); ok {
+						t.Errorf("offset at %s: expected no offset", d)
+					}
+				}
+
+				for i := range starts {
+					end, tend := r.Offset(), ix.Duration()
+					if i+1 < len(starts) {
+						end, tend = starts[i+1], times[i+1]
+					}
+					if d, ok := ix.TimeAt(starts[i]); !ok || d != times[i] {
+						t.Fatalf("time at %d: expected %s, got %s (ok=%t)", starts[i], times[i], d, ok)
+					}
+					if off, ok := ix.OffsetAt(times[i]); !ok || off != starts[i] {
+						t.Fatalf("offset at %s: expected %d, got %d (ok=%t)", times[i], starts[i], off, ok)
+					}
+					mid := (starts[i] + end) / 2
+					d, ok := ix.TimeAt(mid)
+					if !ok || d <= times[i] || d >= tend {
+						t.Fatalf("time at %d: expected time within frame [%s, %s), got %s (ok=%t)", mid, times[i], tend, d, ok)
+					}
+					if off, ok := ix.OffsetAt(d); !ok || (off-mid) > 1 || (mid-off) > 1 {
+						t.Fatalf("offset at %s: expected about %d, got %d (ok=%t)", d, mid, off, ok)
+					}
+				}
+				for _, off := range []int64{starts[0] - 1, r.Offset(), r.Offset() + 1} {
+					if _, ok := ix.TimeAt(off); ok {
+						t.Errorf("time at %d: expected no time", off)
+					}
+				}
+
+				end := r.Offset()
+				for _, tc := range []struct {
+					Start, End time.Duration
+					Range      [2]int64
+					OK         bool
+				}{
+					{0, ix.Duration(), [2]int64{starts[0], end}, true},
+					{0, ix.Duration() + time.Second, [2]int64{starts[0], end}, true},
+					{times[1], times[2], [2]int64{starts[1], starts[2]}, true},
+					{times[1] + 1, times[2] + 1, [2]int64{starts[1], starts[3]}, true},
+					{times[len(times)-1], ix.Duration(), [2]int64{starts[len(starts)-1], end}, true},
+					{times[1], times[1], [2]int64{}, false},
+					{-1, times[1], [2]int64{}, false},
+					{ix.Duration(), ix.Duration() + 1, [2]int64{}, false},
+				} {
+					byteStart, byteEnd, ok := FrameAlignedRange(ix, tc.Start, tc.End)
+					if act := [2]int64{byteStart, byteEnd}; act != tc.Range || ok != tc.OK {
+						t.Errorf("range [%s, %s): expected %v (ok=%t), got %v (ok=%t)", tc.Start, tc.End, tc.Range, tc.OK, act, ok)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIndexBuildTOC(t *testing.T) {
+	buf, _ := testVBR(false)
+	r := NewReader(bytes.NewReader(buf), 16384)
+	if !r.Next() {
+		t.Fatalf("read xing frame: %v", r.Err())
+	}
+	x, ok := ParseXingHeader(*r.Header(), r.Raw()[FrameHeaderSize:])
+	if !ok {
+		t.Fatalf("parse xing header")
+	}
+
+	// the test stream's toc is relative to the start of the xing frame
+	info := r.Offset()
+	audio, err := BuildIndex(bytes.NewReader(buf[info:]), int64(len(buf))-info)
+	if err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if act, exp := audio.toc(-info, int64(len(buf))), x.TOC; act != exp {
+		t.Errorf("expected toc %v, got %v", exp, act)
+	}
+	toc := audio.BuildTOC()
+	for i := range toc {
+		off, _, _ := audio.FrameAt(audio.Duration() * time.Duration(i) / 100)
+		if exp := byte(off * 256 / (int64(len(buf)) - info)); toc[i] != exp {
+			t.Errorf("toc %d: expected %d, got %d", i, exp, toc[i])
+		}
+	}
+	if (&Index{}).BuildTOC() != [100]byte{} {
+		t.Errorf("expected empty toc for empty index")
+	}
+}
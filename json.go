@@ -0,0 +1,146 @@
+package mp3
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+type frameHeaderJSON struct {
+	Version                json.RawMessage `json:"version"`
+	Layer                  json.RawMessage `json:"layer"`
+	Protection             bool            `json:"protection"`
+	Bitrate                *int            `json:"bitrate,omitempty"` // kbit/s
+	BitrateIndex           *int            `json:"bitrate_index,omitempty"`
+	SamplingFrequency      *int            `json:"sampling_frequency,omitempty"` // Hz
+	SamplingFrequencyIndex *int            `json:"sampling_frequency_index,omitempty"`
+	Padding                bool            `json:"padding"`
+	Private                bool            `json:"private"`
+	Mode                   json.RawMessage `json:"mode"`
+	ModeExtension          int             `json:"mode_extension"`
+	Copyright              bool            `json:"copyright"`
+	Original               bool            `json:"original"`
+	Emphasis               json.RawMessage `json:"emphasis"`
+}
+
+// MarshalJSON encodes the header as a JSON object with decoded values. If the
+// bitrate or sampling frequency cannot be decoded, the raw index is used
+// instead.
+func (f FrameHeader) MarshalJSON() ([]byte, error) {
+	v := frameHeaderJSON{
+		Version:       strconv.AppendQuote(nil, f.ID.String()),
+		Layer:         strconv.AppendQuote(nil, f.Layer.String()),
+		Protection:    f.Protection,
+		Padding:       f.Padding,
+		Private:       f.Private,
+		Mode:          strconv.AppendQuote(nil, f.Mode.String()),
+		ModeExtension: int(f.ModeExtension),
+		Copyright:     f.Copyright,
+		Original:      f.Original,
+		Emphasis:      strconv.AppendQuote(nil, f.Emphasis.String()),
+	}
+	if x, ok := f.Bitrate(); ok {
+		v.Bitrate = &x
+	} else {
+		x := int(f.BitrateIndex)
+		v.BitrateIndex = &x
+	}
+	if x, ok := f.SamplingFrequency(); ok {
+		v.SamplingFrequency = &x
+	} else {
+		x := int(f.SamplingFrequencyIndex)
+		v.SamplingFrequencyIndex = &x
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a header encoded by MarshalJSON. The version, layer,
+// mode, and emphasis may either be the decoded strings or the raw values. The
+// bitrate and sampling frequency may either be the decoded values (bitrate and
+// sampling_frequency) or the raw indices (bitrate_index and
+// sampling_frequency_index).
+func (f *FrameHeader) UnmarshalJSON(b []byte) error {
+	var v frameHeaderJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var h FrameHeader
+	if err := unmarshalEnumJSON(v.Version, &h.ID); err != nil {
+		return errors.New("version: " + err.Error())
+	}
+	if err := unmarshalEnumJSON(v.Layer, &h.Layer); err != nil {
+		return errors.New("layer: " + err.Error())
+	}
+	if err := unmarshalEnumJSON(v.Mode, &h.Mode); err != nil {
+		return errors.New("mode: " + err.Error())
+	}
+	if err := unmarshalEnumJSON(v.Emphasis, &h.Emphasis); err != nil {
+		return errors.New("emphasis: " + err.Error())
+	}
+	switch {
+	case v.BitrateIndex != nil:
+		if *v.BitrateIndex < 0 || *v.BitrateIndex > 0b1111 {
+			return errors.New("bitrate_index: out of range")
+		}
+		h.BitrateIndex = BitrateIndex(*v.BitrateIndex)
+	case v.Bitrate != nil:
+		i, ok := BitrateIndexFor(*v.Bitrate, h.ID, h.Layer)
+		if !ok {
+			return errors.New("bitrate: invalid value " + strconv.Itoa(*v.Bitrate) + " for " + h.ID.String() + " " + h.Layer.String())
+		}
+		h.BitrateIndex = i
+	default:
+		return errors.New("bitrate: missing")
+	}
+	switch {
+	case v.SamplingFrequencyIndex != nil:
+		if *v.SamplingFrequencyIndex < 0 || *v.SamplingFrequencyIndex > 0b11 {
+			return errors.New("sampling_frequency_index: out of range")
+		}
+		h.SamplingFrequencyIndex = SamplingFrequencyIndex(*v.SamplingFrequencyIndex)
+	case v.SamplingFrequency != nil:
+		i, ok := SamplingFrequencyIndexFor(*v.SamplingFrequency, h.ID)
+		if !ok {
+			return errors.New("sampling_frequency: invalid value " + strconv.Itoa(*v.SamplingFrequency) + " for " + h.ID.String())
+		}
+		h.SamplingFrequencyIndex = i
+	default:
+		return errors.New("sampling_frequency: missing")
+	}
+	if v.ModeExtension < 0 || v.ModeExtension > 0b11 {
+		return errors.New("mode_extension: out of range")
+	}
+	h.ModeExtension = ModeExtension(v.ModeExtension)
+	h.Protection = v.Protection
+	h.Padding = v.Padding
+	h.Private = v.Private
+	h.Copyright = v.Copyright
+	h.Original = v.Original
+	*f = h
+	return nil
+}
+
+// unmarshalEnumJSON decodes a 2-bit enum value from either a JSON number or a
+// JSON string accepted by UnmarshalText.
+func unmarshalEnumJSON[T ~uint8, P interface {
+	*T
+	encoding.TextUnmarshaler
+}](b json.RawMessage, v P) error {
+	if len(b) == 0 {
+		return errors.New("missing")
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var n int
+		if err := json.Unmarshal(b, &n); err != nil {
+			return errors.New("expected string or number")
+		}
+		if n < 0 || n > 0b11 {
+			return errors.New("out of range")
+		}
+		*v = T(n)
+		return nil
+	}
+	return v.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,57 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestFrameHeaderJSON(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			h := r.Header()
+			b, err := json.Marshal(h)
+			if err != nil {
+				t.Fatalf("marshal %s: %v", h, err)
+			}
+			var g FrameHeader
+			if err := json.Unmarshal(b, &g); err != nil {
+				t.Fatalf("unmarshal %s: %v", b, err)
+			}
+			if g != *h {
+				t.Fatalf("roundtrip %s: expected %s, got %s", b, h, g)
+			}
+		}
+	})
+
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo, ModeExtension: 2, Original: true}
+	b, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if exp := `{"version":"mpeg-1","layer":"layer-3","protection":false,"bitrate":128,"sampling_frequency":44100,"padding":false,"private":false,"mode":"joint-stereo","mode_extension":2,"copyright":false,"original":true,"emphasis":"none"}`; string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+
+	for _, tc := range []struct {
+		JSON string
+		OK   bool
+	}{
+		{`{"version":3,"layer":1,"protection":false,"bitrate_index":9,"sampling_frequency_index":0,"mode":1,"mode_extension":2,"original":true,"emphasis":0}`, true},
+		{`{"version":"mpeg-1","layer":1,"bitrate":128,"sampling_frequency_index":0,"mode":"joint-stereo","mode_extension":2,"original":true,"emphasis":"none"}`, true},
+		{`{"version":"mpeg-1","layer":"layer-3","bitrate":129,"sampling_frequency":44100,"mode":"joint-stereo","mode_extension":2,"original":true,"emphasis":"none"}`, false},
+		{`{"version":"mpeg-1","layer":"layer-3","bitrate":128,"sampling_frequency":22050,"mode":"joint-stereo","mode_extension":2,"original":true,"emphasis":"none"}`, false},
+		{`{"version":"mpeg-3","layer":"layer-3","bitrate":128,"sampling_frequency":44100,"mode":"joint-stereo","mode_extension":2,"original":true,"emphasis":"none"}`, false},
+		{`{"version":4,"layer":"layer-3","bitrate":128,"sampling_frequency":44100,"mode":"joint-stereo","mode_extension":2,"original":true,"emphasis":"none"}`, false},
+		{`{"version":"mpeg-1","layer":"layer-3","sampling_frequency":44100,"mode":"joint-stereo","mode_extension":2,"original":true,"emphasis":"none"}`, false},
+		{`{"version":"mpeg-1","layer":"layer-3","bitrate":128,"sampling_frequency":44100,"mode":"joint-stereo","mode_extension":4,"original":true,"emphasis":"none"}`, false},
+	} {
+		var g FrameHeader
+		if err := json.Unmarshal([]byte(tc.JSON), &g); (err == nil) != tc.OK {
+			t.Errorf("unmarshal %s: expected ok=%t, got error %v", tc.JSON, tc.OK, err)
+		} else if tc.OK && g != h {
+			t.Errorf("unmarshal %s: expected %s, got %s", tc.JSON, h, g)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package mp3
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// maxMainDataBegin is the largest possible value of main_data_begin.
+const maxMainDataBegin = 1<<9 - 1
+
+// SideInfoSize gets the size in bytes of the Layer III side information, which
+// follows the header and the parity-check word.
+func (f FrameHeader) SideInfoSize() (int, bool) {
+	if f.Layer != MPEGLayerIII {
+		return -1, false
+	}
+	switch f.ID {
+	case MPEGVersion1:
+		if f.IsMono() {
+			return 17, true
+		}
+		return 32, true
+	case MPEGVersion2, MPEGVersion2_5:
+		if f.IsMono() {
+			return 9, true
+		}
+		return 17, true
+	}
+	return -1, false
+}
+
+// SideInfoIII contains the Layer III side information.
+type SideInfoIII struct {
+	// MainDataBegin is the negative offset in bytes of the first byte of the
+	// main data from the first byte after the side information, not counting
+	// headers and side information from previous frames.
+	MainDataBegin uint16
+	// PrivateBits is for private use.
+	PrivateBits uint8
+	// SCFSI contains the scale factor selection information for each channel
+	// and scale factor band. It is not present for MPEG-2/2.5.
+	SCFSI [2][4]bool
+	// Granules contains information for each granule and channel. MPEG-2/2.5
+	// only has one granule per frame.
+	Granules [2][2]GranuleIII
+}
+
+// GranuleIII contains the Layer III side information for a single granule of a
+// single channel.
+type GranuleIII struct {
+	Part2_3Length     uint16
+	BigValues         uint16
+	GlobalGain        uint8
+	ScalefacCompress  uint16 // 4 bits for MPEG-1, 9 bits for MPEG-2/2.5
+	WindowSwitching   bool
+	BlockType         uint8
+	MixedBlock        bool
+	TableSelect       [3]uint8
+	SubblockGain      [3]uint8
+	Region0Count      uint8
+	Region1Count      uint8
+	Preflag           bool // not present for MPEG-2/2.5
+	ScalefacScale     bool
+	Count1TableSelect bool
+}
+
+// mainDataSize returns the size in bytes of the main data (i.e., the sum of
+// part2_3_length) for all granules and channels.
+func (si SideInfoIII) mainDataSize() int {
+	var bits int
+	for _, gr := range si.Granules {
+		for _, g := range gr {
+			bits += int(g.Part2_3Length)
+		}
+	}
+	return (bits + 7) / 8
+}
+
+// ParseSideInfoIII parses the Layer III side information from the frame data
+// following the header and the parity-check word (i.e., [Reader.Data]).
+func ParseSideInfoIII(f FrameHeader, body []byte) (SideInfoIII, error) {
+	var si SideInfoIII
+	size, ok := f.SideInfoSize()
+	if !ok {
+		return si, errors.New("not a valid layer 3 frame")
+	}
+	if len(body) < size {
+		return si, errors.New("frame data too short for side information (" + strconv.Itoa(len(body)) + " < " + strconv.Itoa(size) + ")")
+	}
+
+	lsf := f.ID != MPEGVersion1
+	nch := f.ChannelCount()
+	ngr := 2
+	if lsf {
+		ngr = 1
+	}
+
+	br := bitReader{b: body[:size]}
+	if lsf {
+		si.MainDataBegin = uint16(br.bits(8))
+		si.PrivateBits = uint8(br.bits(nch))
+	} else {
+		si.MainDataBegin = uint16(br.bits(9))
+		if nch == 1 {
+			si.PrivateBits = uint8(br.bits(5))
+		} else {
+			si.PrivateBits = uint8(br.bits(3))
+		}
+		for ch := range nch {
+			for band := range si.SCFSI[ch] {
+				si.SCFSI[ch][band] = br.flag()
+			}
+		}
+	}
+	for gr := range ngr {
+		for ch := range nch {
+			g := &si.Granules[gr][ch]
+			g.Part2_3Length = uint16(br.bits(12))
+			g.BigValues = uint16(br.bits(9))
+			g.GlobalGain = uint8(br.bits(8))
+			if lsf {
+				g.ScalefacCompress = uint16(br.bits(9))
+			} else {
+				g.ScalefacCompress = uint16(br.bits(4))
+			}
+			if g.WindowSwitching = br.flag(); g.WindowSwitching {
+				g.BlockType = uint8(br.bits(2))
+				g.MixedBlock = br.flag()
+				for i := range 2 {
+					g.TableSelect[i] = uint8(br.bits(5))
+				}
+				for i := range 3 {
+					g.SubblockGain[i] = uint8(br.bits(3))
+				}
+			} else {
+				for i := range 3 {
+					g.TableSelect[i] = uint8(br.bits(5))
+				}
+				g.Region0Count = uint8(br.bits(4))
+				g.Region1Count = uint8(br.bits(3))
+			}
+			if !lsf {
+				g.Preflag = br.flag()
+			}
+			g.ScalefacScale = br.flag()
+			g.Count1TableSelect = br.flag()
+		}
+	}
+	return si, nil
+}
+
+// AnalyzeReservoir reads all frames from r using a [Reader] with the specified
+// buffer size, returning the largest and average main_data_begin (i.e., the
+// number of bytes of main data taken from the bit reservoir) of the Layer III
+// frames. Frames which are not Layer III are ignored.
+func AnalyzeReservoir(r io.Reader, buffer int) (maxBegin int, avgBegin float64, err error) {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return 0, 0, err
+	}
+	var (
+		frames int
+		total  int64
+	)
+	for rd.Next() {
+		if begin, ok := rd.MainDataBegin(); ok {
+			maxBegin = max(maxBegin, begin)
+			total += int64(begin)
+			frames++
+		}
+	}
+	if frames != 0 {
+		avgBegin = float64(total) / float64(frames)
+	}
+	return maxBegin, avgBegin, rd.Err()
+}
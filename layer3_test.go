@@ -0,0 +1,123 @@
+package mp3
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSideInfoIII(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		// this one starts in the middle of a stream
+		cut := strings.HasSuffix(t.Name(), "/layer3/sin1k0db")
+
+		var n, reservoir int
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			n++
+			if r.Header().Layer != MPEGLayerIII {
+				return
+			}
+			si, err := ParseSideInfoIII(*r.Header(), r.Data())
+			if err != nil {
+				t.Fatalf("frame %d: parse side info: %v", n, err)
+			}
+			if v, ok := r.MainDataBegin(); !ok || v != int(si.MainDataBegin) {
+				t.Errorf("frame %d: expected main_data_begin %d, got %d", n, si.MainDataBegin, v)
+			}
+			if n == 1 && si.MainDataBegin != 0 && !cut {
+				t.Errorf("frame %d: expected main_data_begin to be 0 for the first frame, got %d", n, si.MainDataBegin)
+			}
+			if int(si.MainDataBegin) > reservoir && !cut {
+				t.Errorf("frame %d: main_data_begin %d exceeds reservoir size %d", n, si.MainDataBegin, reservoir)
+			}
+
+			size, _ := r.Header().SideInfoSize()
+			main := len(r.Raw()) - FrameHeaderSize - size
+			if r.Header().Protection {
+				main -= 2
+			}
+
+			var bits int
+			for _, gr := range si.Granules {
+				for _, g := range gr {
+					bits += int(g.Part2_3Length)
+				}
+			}
+			if avail := (int(si.MainDataBegin) + main) * 8; bits > avail {
+				t.Errorf("frame %d: part2_3_length total %d exceeds available main data bits %d", n, bits, avail)
+			}
+			if md, ok := r.LogicalMainData(); ok {
+				if exp := (bits + 7) / 8; len(md) != exp {
+					t.Errorf("frame %d: expected %d bytes of logical main data, got %d", n, exp, len(md))
+				}
+			} else if !cut {
+				t.Errorf("frame %d: failed to get logical main data", n)
+			}
+			if anc, ok := r.Ancillary(); !ok {
+				t.Errorf("frame %d: failed to get ancillary data", n)
+			} else if exp := main - max((bits+7)/8-int(si.MainDataBegin), 0); len(anc) != exp {
+				t.Errorf("frame %d: expected %d bytes of ancillary data, got %d", n, exp, len(anc))
+			}
+			reservoir = min(reservoir+main, maxMainDataBegin)
+		}
+	})
+}
+
+func TestAncillary(t *testing.T) {
+	buf, _ := testVBR(false)
+	r := NewReader(bytes.NewReader(buf), 16384)
+	if !r.Next() {
+		t.Fatalf("read frame: %v", r.Err())
+	}
+	if anc, ok := r.Ancillary(); !ok || !bytes.HasPrefix(anc, []byte("Xing")) {
+		t.Errorf("expected ancillary data to contain the xing header, got %q", anc)
+	}
+
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 9, Mode: ModeJointStereo}
+	frame, err := h.SilentFrame()
+	if err != nil {
+		panic(err)
+	}
+	r = NewReader(bytes.NewReader(frame), 16384)
+	if !r.Next() {
+		t.Fatalf("read frame: %v", r.Err())
+	}
+	if _, ok := r.Ancillary(); ok {
+		t.Errorf("expected no ancillary data for layer 2")
+	}
+}
+
+func TestAnalyzeReservoir(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var frames, maxBegin, total int
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			if begin, ok := r.MainDataBegin(); ok {
+				maxBegin = max(maxBegin, begin)
+				total += begin
+				frames++
+			}
+		}
+		m, avg, err := AnalyzeReservoir(bytes.NewReader(buf), 16384)
+		if !sameError(err, r.Err()) {
+			t.Errorf("expected error %v, got %v", r.Err(), err)
+		}
+		if m != maxBegin {
+			t.Errorf("expected max main_data_begin %d, got %d", maxBegin, m)
+		}
+		if frames == 0 {
+			if avg != 0 {
+				t.Errorf("expected no average main_data_begin, got %f", avg)
+			}
+		} else if exp := float64(total) / float64(frames); avg != exp {
+			t.Errorf("expected average main_data_begin %f, got %f", exp, avg)
+		}
+		if avg > float64(m) || m > maxMainDataBegin {
+			t.Errorf("invalid main_data_begin stats %d %f", m, avg)
+		}
+	})
+	if _, _, err := AnalyzeReservoir(bytes.NewReader(nil), 0); err == nil {
+		t.Errorf("expected error for invalid buffer size")
+	}
+}
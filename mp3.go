@@ -10,15 +10,52 @@ package mp3
 //  - https://ossrs.io/lts/zh-cn/assets/files/ISO_IEC_13818-3-MP3-1997-8bbd47f7cd4e0325f23b9473f6932fa1.pdf
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"io"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var ErrUnsynchronized = errors.New("no syncword found")
 
+var (
+	ErrInvalidVersion    = errors.New("invalid mpeg version")
+	ErrInvalidLayer      = errors.New("invalid mpeg layer")
+	ErrInvalidBitrate    = errors.New("invalid bitrate index")
+	ErrInvalidSampleRate = errors.New("invalid sampling frequency index")
+	ErrInvalidMode       = errors.New("invalid mode")
+	ErrInvalidEmphasis   = errors.New("invalid emphasis")
+)
+
+// Errors for values which fit in the header field, but are reserved by the
+// specification. They also match the corresponding ErrInvalid* error with
+// [errors.Is].
+var (
+	ErrReservedVersion  error = wrapError{"reserved mpeg version", ErrInvalidVersion}
+	ErrReservedLayer    error = wrapError{"reserved mpeg layer", ErrInvalidLayer}
+	ErrReservedEmphasis error = wrapError{"reserved emphasis", ErrInvalidEmphasis}
+)
+
+// wrapError is an error with a specific message which also matches a more
+// general one.
+type wrapError struct {
+	msg     string
+	invalid error
+}
+
+func (err wrapError) Error() string {
+	return err.msg
+}
+
+func (err wrapError) Unwrap() error {
+	return err.invalid
+}
+
 type MPEGVersion uint8 // 2 bits
 
 const (
@@ -28,6 +65,11 @@ const (
 	MPEGVersion1
 )
 
+// Reserved returns true if x is [MPEGVersionReserved].
+func (x MPEGVersion) Reserved() bool {
+	return x == MPEGVersionReserved
+}
+
 type MPEGLayer uint8 // 2 bits
 
 const (
@@ -37,6 +79,11 @@ const (
 	MPEGLayerI
 )
 
+// Reserved returns true if x is [MPEGLayerReserved].
+func (x MPEGLayer) Reserved() bool {
+	return x == MPEGLayerReserved
+}
+
 type Mode uint8 // 2 bits
 
 const (
@@ -80,6 +127,11 @@ const (
 	EmphasisCCITT_J_17
 )
 
+// Reserved returns true if x is [EmphasisReserved].
+func (x Emphasis) Reserved() bool {
+	return x == EmphasisReserved
+}
+
 type BitrateIndex uint8 // 4 bits
 
 // BitrateIndexFree indicates the "free format" condition, in which a fixed
@@ -112,6 +164,18 @@ func (i BitrateIndex) Bitrate(version MPEGVersion, layer MPEGLayer) (int, bool)
 	return -1, false
 }
 
+// BitrateIndexFor gets the bitrate index for the specified bitrate in kbit/s.
+// A bitrate of 0 is [BitrateIndexFree]. If the bitrate cannot be represented
+// for the version and layer, false is returned.
+func BitrateIndexFor(kbps int, version MPEGVersion, layer MPEGLayer) (BitrateIndex, bool) {
+	for i := BitrateIndex(0); i < 0b1111; i++ {
+		if x, ok := i.Bitrate(version, layer); ok && x == kbps {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 type SamplingFrequencyIndex uint8 // 2 bits
 
 func (i SamplingFrequencyIndex) SamplingFrequency(version MPEGVersion) (int, bool) {
@@ -128,6 +192,18 @@ func (i SamplingFrequencyIndex) SamplingFrequency(version MPEGVersion) (int, boo
 	return -1, false
 }
 
+// SamplingFrequencyIndexFor gets the sampling frequency index for the specified
+// sampling frequency in Hz. If the sampling frequency cannot be represented
+// for the version, false is returned.
+func SamplingFrequencyIndexFor(hz int, version MPEGVersion) (SamplingFrequencyIndex, bool) {
+	for i := SamplingFrequencyIndex(0); i < 0b11; i++ {
+		if x, ok := i.SamplingFrequency(version); ok && x == hz {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 // SampleCount is the number of samples a frame contains information for.
 //
 // In [MPEGLayerI] and [MPEGLayerII], each frame is standalone. In
@@ -204,6 +280,29 @@ type FrameHeader struct {
 	Emphasis Emphasis
 }
 
+// NewFrameHeader creates a valid frame header with the specified bitrate in
+// kbit/s (or 0 for free format) and sampling frequency in Hz. If the
+// combination cannot be represented, an error is returned. The remaining fields
+// are left as zero.
+func NewFrameHeader(version MPEGVersion, layer MPEGLayer, bitrate, sampleRate int, mode Mode) (FrameHeader, error) {
+	f := FrameHeader{
+		ID:    version,
+		Layer: layer,
+		Mode:  mode,
+	}
+	if err := f.Valid(); err != nil {
+		return FrameHeader{}, err // version, layer, or mode
+	}
+	var ok bool
+	if f.BitrateIndex, ok = BitrateIndexFor(bitrate, version, layer); !ok {
+		return FrameHeader{}, ErrInvalidBitrate
+	}
+	if f.SamplingFrequencyIndex, ok = SamplingFrequencyIndexFor(sampleRate, version); !ok {
+		return FrameHeader{}, ErrInvalidSampleRate
+	}
+	return f, nil
+}
+
 // FrameHeaderSize is the length of an encoded [FrameHeader] in bytes.
 const FrameHeaderSize = 4
 
@@ -223,6 +322,103 @@ func (f FrameHeader) SlotSize() (int, bool) {
 	return SlotSize(f.ID, f.Layer)
 }
 
+// Duration gets the playback duration of the frame.
+func (f FrameHeader) Duration() (time.Duration, bool) {
+	sampleCount, ok := f.SampleCount()
+	if !ok {
+		return 0, false
+	}
+	samplingFrequency, ok := f.SamplingFrequency()
+	if !ok {
+		return 0, false
+	}
+	return time.Second * time.Duration(sampleCount) / time.Duration(samplingFrequency), true
+}
+
+// ChannelCount gets the number of audio channels.
+func (f FrameHeader) ChannelCount() int {
+	if f.IsMono() {
+		return 1
+	}
+	return 2
+}
+
+// IsMono returns true if the mode is [ModeSingleChannel].
+func (f FrameHeader) IsMono() bool {
+	return f.Mode == ModeSingleChannel
+}
+
+// Bound gets the first subband in intensity_stereo for [MPEGLayerI] and
+// [MPEGLayerII]. For [ModeJointStereo], this is the bound from the
+// [ModeExtension] (limited to sblimit), and for other modes, it is sblimit,
+// which is the number of subbands with allocated bits. For Layer II, sblimit
+// depends on the bitrate and sampling frequency, so it cannot be determined in
+// free format.
+func (f FrameHeader) Bound() (int, bool) {
+	sblimit, ok := f.sblimit()
+	if !ok {
+		return -1, false
+	}
+	if f.Mode == ModeJointStereo {
+		bound, ok := f.ModeExtension.Bound()
+		if !ok {
+			return -1, false
+		}
+		return min(bound, sblimit), true
+	}
+	return sblimit, true
+}
+
+// StereoCoding gets the type of joint stereo coding used for [MPEGLayerIII].
+// If the mode is not [ModeJointStereo], both intensityStereo and msStereo are
+// false.
+func (f FrameHeader) StereoCoding() (intensityStereo, msStereo, ok bool) {
+	if f.Layer != MPEGLayerIII {
+		return false, false, false
+	}
+	if f.Mode != ModeJointStereo {
+		return false, false, f.Mode <= 0b11
+	}
+	return f.ModeExtension.Coding()
+}
+
+// sblimit gets the number of subbands with allocated bits for [MPEGLayerI] and
+// [MPEGLayerII]. For Layer II, it depends on the allocation table selected
+// based on the bitrate per channel and the sampling frequency.
+func (f FrameHeader) sblimit() (int, bool) {
+	switch f.Layer {
+	case MPEGLayerI:
+		return 32, true
+	case MPEGLayerII:
+		switch f.ID {
+		case MPEGVersion2, MPEGVersion2_5:
+			return 30, true
+		case MPEGVersion1:
+		default:
+			return -1, false
+		}
+		bitrate, ok := f.Bitrate()
+		if !ok || bitrate == 0 {
+			return -1, false
+		}
+		samplingFrequency, ok := f.SamplingFrequency()
+		if !ok {
+			return -1, false
+		}
+		switch perChannel := bitrate / f.ChannelCount(); {
+		case (samplingFrequency == 48000 && perChannel >= 56) || (perChannel >= 56 && perChannel <= 80):
+			return 27, true
+		case samplingFrequency != 48000 && perChannel >= 96:
+			return 30, true
+		case samplingFrequency != 32000 && perChannel <= 48:
+			return 8, true
+		default:
+			return 12, true
+		}
+	}
+	return -1, false
+}
+
 // Slots gets the number of slots used for the frame and whether the result was
 // truncated. If the result was truncated, the number of slots between syncwords
 // will vary between N and N+1.
@@ -243,43 +439,178 @@ func (f FrameHeader) Slots() (int, bool, bool) {
 			case MPEGLayerI:
 				x := 12 * bitrate
 				return x / samplingFrequency, x%samplingFrequency != 0, true
-			case MPEGLayerII, MPEGLayerIII:
+			case MPEGLayerII:
 				x := 144 * bitrate
 				return x / samplingFrequency, x%samplingFrequency != 0, true
+			case MPEGLayerIII:
+				// half as many samples for MPEG-2/2.5 (see SampleCount)
+				x := 144 * bitrate
+				if f.ID != MPEGVersion1 {
+					x = 72 * bitrate
+				}
+				return x / samplingFrequency, x%samplingFrequency != 0, true
 			}
 		}
 	}
 	return 0, false, false
 }
 
+// FrameSize gets the total size of the frame in bytes, including the header,
+// crc, data, and padding.
+//
+// If bitrate is the free bitrate, false is returned, and the size must be
+// determined by looking at the distance until the next syncword.
+func (f FrameHeader) FrameSize() (int, bool) {
+	if f.BitrateIndex == BitrateIndexFree {
+		return -1, false
+	}
+	slots, _, ok := f.Slots()
+	if !ok {
+		return -1, false
+	}
+	slotSize, ok := f.SlotSize()
+	if !ok {
+		return -1, false
+	}
+	if f.Padding {
+		slots++
+	}
+	return slots * slotSize, true
+}
+
+// SlotsFor is the inverse of Slots. Given the distance in bytes between the
+// syncword of this frame and the next one, it gets the number of slots N and
+// whether the distance includes a padding slot.
+//
+// For free format frames, the padding bit is used to determine whether the
+// distance includes a padding slot. Otherwise, the distance must be consistent
+// with the bitrate.
+func (f FrameHeader) SlotsFor(distance int) (slots int, padding bool, ok bool) {
+	slotSize, ok := f.SlotSize()
+	if !ok || distance < FrameHeaderSize || distance%slotSize != 0 {
+		return 0, false, false
+	}
+	total := distance / slotSize
+	if f.BitrateIndex == BitrateIndexFree {
+		if f.Padding {
+			return total - 1, true, true
+		}
+		return total, false, true
+	}
+	n, _, ok := f.Slots()
+	if !ok {
+		return 0, false, false
+	}
+	switch total {
+	case n:
+		return n, false, true
+	case n + 1:
+		return n, true, true
+	}
+	return 0, false, false
+}
+
+// freeBitrate gets the bitrate in kbit/s of a free format frame with the
+// specified number of slots (excluding padding), rounded to the nearest kbit/s.
+// It is the inverse of Slots.
+func (f FrameHeader) freeBitrate(slots int) (int, bool) {
+	samplingFrequency, ok := f.SamplingFrequency()
+	if !ok || slots <= 0 {
+		return 0, false
+	}
+	var x int
+	switch f.Layer {
+	case MPEGLayerI:
+		x = 12
+	case MPEGLayerII:
+		x = 144
+	case MPEGLayerIII:
+		x = 144
+		if f.ID != MPEGVersion1 {
+			x = 72
+		}
+	default:
+		return 0, false
+	}
+	return (slots*samplingFrequency + x*500) / (x * 1000), true
+}
+
+// Equal returns true if all fields of f and g are the same.
+func (f FrameHeader) Equal(g FrameHeader) bool {
+	return f == g
+}
+
+// Compatible returns true if f and g have the same version, layer, sampling
+// frequency, and number of channels (i.e., a decoder does not need to be
+// reinitialized between them). The bitrate, padding, mode extension, and flags
+// are ignored.
+func (f FrameHeader) Compatible(g FrameHeader) bool {
+	return f.ID == g.ID &&
+		f.Layer == g.Layer &&
+		f.SamplingFrequencyIndex == g.SamplingFrequencyIndex &&
+		f.ChannelCount() == g.ChannelCount()
+}
+
 func (f FrameHeader) Valid() error {
 	switch f.ID {
 	case MPEGVersion1, MPEGVersion2, MPEGVersion2_5:
+	case MPEGVersionReserved:
+		return ErrReservedVersion
 	default:
-		return errors.New("invalid mpeg version")
+		return ErrInvalidVersion
 	}
 	switch f.Layer {
 	case MPEGLayerI, MPEGLayerII, MPEGLayerIII:
+	case MPEGLayerReserved:
+		return ErrReservedLayer
 	default:
-		return errors.New("invalid mpeg layer")
+		return ErrInvalidLayer
 	}
 	if _, ok := f.BitrateIndex.Bitrate(f.ID, f.Layer); !ok {
-		return errors.New("invalid bitrate index")
+		return ErrInvalidBitrate
 	}
 	if _, ok := f.SamplingFrequencyIndex.SamplingFrequency(f.ID); !ok {
-		return errors.New("invalid sampling frequency index")
+		return ErrInvalidSampleRate
 	}
 	switch f.Mode {
 	case ModeStereo, ModeDualChannel, ModeSingleChannel:
 		// note: we don't need to validate the ModeExtension; it's redundant if it's not 0, but it's not an error
 	case ModeJointStereo:
 	default:
-		return errors.New("invalid mode")
+		return ErrInvalidMode
 	}
 	switch f.Emphasis {
 	case EmphasisNone, Emphasis50_15, EmphasisCCITT_J_17:
+	case EmphasisReserved:
+		return ErrReservedEmphasis
 	default:
-		return errors.New("invalid emphasis")
+		return ErrInvalidEmphasis
+	}
+	return nil
+}
+
+// ValidStrict is like [FrameHeader.Valid], but also checks that the bitrate is
+// allowed for the mode. For MPEG-1 Layer II, 32, 48, 56, and 80 kbit/s are
+// only allowed for single channel, and 224, 256, 320, and 384 kbit/s are only
+// allowed for the other modes. The error matches [ErrInvalidBitrate].
+func (f FrameHeader) ValidStrict() error {
+	if err := f.Valid(); err != nil {
+		return err
+	}
+	if f.ID == MPEGVersion1 && f.Layer == MPEGLayerII {
+		bitrate, _ := f.Bitrate()
+		var ok bool
+		switch bitrate {
+		case 32, 48, 56, 80:
+			ok = f.IsMono()
+		case 224, 256, 320, 384:
+			ok = !f.IsMono()
+		default:
+			ok = true
+		}
+		if !ok {
+			return wrapError{"bitrate " + strconv.Itoa(bitrate) + " kbit/s is not allowed for " + f.Mode.String() + " " + f.ID.String() + " " + f.Layer.String(), ErrInvalidBitrate}
+		}
 	}
 	return nil
 }
@@ -333,8 +664,27 @@ func boolBit(b bool) uint8 {
 	return 0
 }
 
+// headerBufs contains buffers for ReadFrom and WriteTo, which would otherwise
+// need to be allocated on every call since they escape through the interface.
+var headerBufs = sync.Pool{
+	New: func() any { return new([FrameHeaderSize]byte) },
+}
+
 func (f *FrameHeader) ReadFrom(r io.Reader) (n int64, err error) {
-	b := make([]byte, FrameHeaderSize)
+	b := headerBufs.Get().(*[FrameHeaderSize]byte)
+	n, err = f.ReadFromBuf(r, b[:])
+	headerBufs.Put(b)
+	return n, err
+}
+
+// ReadFromBuf is like ReadFrom, but reads into buf, which must be at least
+// [FrameHeaderSize] bytes long. Since the buffer is provided by the caller, it
+// does not allocate.
+func (f *FrameHeader) ReadFromBuf(r io.Reader, buf []byte) (n int64, err error) {
+	if len(buf) < FrameHeaderSize {
+		return 0, errors.New("buffer too small for frame header")
+	}
+	b := buf[:FrameHeaderSize]
 	nn, err := io.ReadFull(r, b)
 	if err != nil {
 		return int64(nn), err
@@ -358,16 +708,15 @@ func (f *FrameHeader) UnmarshalBinary(b []byte) error {
 }
 
 func (f FrameHeader) WriteTo(w io.Writer) (n int64, err error) {
-	b := make([]byte, FrameHeaderSize)
-	f.encode(b)
-	nn, err := w.Write(b)
+	b := headerBufs.Get().(*[FrameHeaderSize]byte)
+	f.encode(b[:])
+	nn, err := w.Write(b[:])
+	headerBufs.Put(b)
 	return int64(nn), err
 }
 
 func (f FrameHeader) MarshalBinary() ([]byte, error) {
-	b := make([]byte, FrameHeaderSize)
-	f.encode(b)
-	return b, nil
+	return f.AppendBinary(make([]byte, 0, FrameHeaderSize))
 }
 
 func (f FrameHeader) AppendBinary(b []byte) ([]byte, error) {
@@ -377,22 +726,191 @@ func (f FrameHeader) AppendBinary(b []byte) ([]byte, error) {
 	return b, nil
 }
 
+// DecodeHeaderUint32 decodes a frame header from the big-endian integer v (i.e.,
+// the first byte of the header is the most significant byte). If the syncword
+// is not present, false is returned. The header is not validated.
+func DecodeHeaderUint32(v uint32) (FrameHeader, bool) {
+	var f FrameHeader
+	if v&0xFFE0_0000 != 0xFFE0_0000 {
+		return f, false
+	}
+	var b [FrameHeaderSize]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	f.decode(b[:])
+	return f, true
+}
+
+// Uint32 encodes the frame header as a big-endian integer (see
+// [DecodeHeaderUint32]).
+func (f FrameHeader) Uint32() uint32 {
+	var b [FrameHeaderSize]byte
+	f.encode(b[:])
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// AppendFrame appends the encoded header followed by body, which is the
+// remainder of the frame. If the frame is protected, body must begin with the
+// parity-check word, which will be replaced with a newly computed one.
+func (f FrameHeader) AppendFrame(dst, body []byte) ([]byte, error) {
+	var crc uint16
+	if f.Protection {
+		if len(body) < 2 {
+			return dst, errors.New("frame body too short for error check")
+		}
+		var ok bool
+		if crc, ok = ComputeErrorCheck(f, body[2:]); !ok {
+			return dst, errors.New("cannot compute error check for frame")
+		}
+	}
+	dst, _ = f.AppendBinary(dst)
+	n := len(dst)
+	dst = append(dst, body...)
+	if f.Protection {
+		binary.BigEndian.PutUint16(dst[n:], crc)
+	}
+	return dst, nil
+}
+
+// SilentFrame creates a complete frame which decodes to silence. Since all bit
+// allocations (Layer I and II) or the side information (Layer III) are zero,
+// the remainder of the frame is also zero. For Layer III, main_data_begin is
+// zero, so the frame does not depend on the bit reservoir. If the header is
+// invalid or is free format, or the parity-check word cannot be computed, an
+// error is returned.
+func (f FrameHeader) SilentFrame() ([]byte, error) {
+	if err := f.Valid(); err != nil {
+		return nil, err
+	}
+	size, ok := f.FrameSize()
+	if !ok {
+		return nil, errors.New("cannot determine frame size")
+	}
+	return f.AppendFrame(make([]byte, 0, size), make([]byte, size-FrameHeaderSize))
+}
+
 // Sync attempts to find the index of the first syncword. If none is found, -1
 // is returned.
 func Sync(b []byte) int {
-	for i := range b {
-		if IsSyncword(b[i:]) {
+	for i := 0; ; {
+		j := bytes.IndexByte(b[i:], 0b1111_1111)
+		if j == -1 {
+			return -1
+		}
+		if i += j; IsSyncword(b[i:]) {
 			return i
 		}
+		i++
 	}
-	return -1
 }
 
 func IsSyncword(b []byte) bool {
 	return len(b) >= 2 && b[0] == 0b1111_1111 && b[1]&0b1110_0000 == 0b1110_0000
 }
 
-// TODO: func ComputeErrorCheck(f Frame, ...) uint16
+// syncFree finds the index of the next header after the free format header at
+// the start of b with the same version, layer, protection, bitrate index, and
+// sampling frequency. If none is found, -1 is returned.
+func syncFree(b []byte) int {
+	for i := FrameHeaderSize; i < len(b); i++ {
+		j := Sync(b[i:])
+		if j == -1 {
+			break
+		}
+		i += j
+		if len(b)-i >= FrameHeaderSize && b[i+1] == b[1] && b[i+2]&0b1111_1100 == b[2]&0b1111_1100 {
+			return i
+		}
+	}
+	return -1
+}
+
+// frameSizeAt gets the size of the frame with header f at the start of b. For
+// free format frames, the number of slots (excluding the padding slot) is
+// measured from the distance to the next header in b (see syncFree) if *free is
+// 0, and stored in *free for subsequent frames. If the size cannot be
+// determined, false is returned.
+func frameSizeAt(f FrameHeader, b []byte, free *int) (int, bool) {
+	if f.BitrateIndex != BitrateIndexFree {
+		size, ok := f.FrameSize()
+		return size, ok && size >= FrameHeaderSize
+	}
+	slotSize, ok := f.SlotSize()
+	if !ok {
+		return 0, false
+	}
+	if *free == 0 {
+		i := syncFree(b)
+		if i == -1 {
+			return 0, false
+		}
+		n, _, ok := f.SlotsFor(i)
+		if !ok {
+			return 0, false
+		}
+		*free = n
+	}
+	size := *free * slotSize
+	if f.Padding {
+		size += slotSize
+	}
+	return size, size >= FrameHeaderSize
+}
+
+// Probe attempts to find the first valid frame in b. To reduce false positives,
+// a frame is only accepted if it is immediately followed by the header of
+// another valid frame with the same version, layer, and sampling frequency.
+// If no frame is found, false is returned.
+func Probe(b []byte) (FrameHeader, int, bool) {
+	for i := 0; i < len(b); i++ {
+		j := Sync(b[i:])
+		if j == -1 {
+			break
+		}
+		i += j
+		if f, ok, _ := probeFrame(b[i:], false); ok {
+			return f, i, true
+		}
+	}
+	return FrameHeader{}, -1, false
+}
+
+// probeFrame checks if b starts with a valid frame immediately followed by the
+// header of another valid frame with the same version, layer, and sampling
+// frequency. If eof is true, a frame ending exactly at the end of b is also
+// accepted. If eof is false and b is too short to check, more is true.
+func probeFrame(b []byte, eof bool) (f FrameHeader, ok, more bool) {
+	if !IsSyncword(b) {
+		return f, false, false
+	}
+	if len(b) < FrameHeaderSize {
+		return f, false, !eof
+	}
+	f.decode(b)
+	if f.Valid() != nil {
+		return f, false, false
+	}
+	size, ok := f.FrameSize()
+	if !ok {
+		if size = syncFree(b); size == -1 {
+			return f, false, !eof
+		}
+	}
+	if eof && len(b) == size {
+		return f, true, false
+	}
+	if len(b)-size < FrameHeaderSize {
+		return f, false, !eof
+	}
+	if !IsSyncword(b[size:]) {
+		return f, false, false
+	}
+	var g FrameHeader
+	g.decode(b[size:])
+	if g.Valid() != nil || g.ID != f.ID || g.Layer != f.Layer || g.SamplingFrequencyIndex != f.SamplingFrequencyIndex {
+		return f, false, false
+	}
+	return f, true, false
+}
 
 func (x MPEGVersion) String() string {
 	switch x {
@@ -448,6 +966,157 @@ func (x Emphasis) String() string {
 	}
 }
 
+func (x MPEGVersion) GoString() string {
+	switch x {
+	case MPEGVersion1:
+		return "mp3.MPEGVersion1"
+	case MPEGVersion2:
+		return "mp3.MPEGVersion2"
+	case MPEGVersion2_5:
+		return "mp3.MPEGVersion2_5"
+	case MPEGVersionReserved:
+		return "mp3.MPEGVersionReserved"
+	default:
+		return "mp3.MPEGVersion(" + strconv.Itoa(int(x)) + ")"
+	}
+}
+
+func (x MPEGLayer) GoString() string {
+	switch x {
+	case MPEGLayerIII:
+		return "mp3.MPEGLayerIII"
+	case MPEGLayerII:
+		return "mp3.MPEGLayerII"
+	case MPEGLayerI:
+		return "mp3.MPEGLayerI"
+	case MPEGLayerReserved:
+		return "mp3.MPEGLayerReserved"
+	default:
+		return "mp3.MPEGLayer(" + strconv.Itoa(int(x)) + ")"
+	}
+}
+
+func (x Mode) GoString() string {
+	switch x {
+	case ModeStereo:
+		return "mp3.ModeStereo"
+	case ModeJointStereo:
+		return "mp3.ModeJointStereo"
+	case ModeDualChannel:
+		return "mp3.ModeDualChannel"
+	case ModeSingleChannel:
+		return "mp3.ModeSingleChannel"
+	default:
+		return "mp3.Mode(" + strconv.Itoa(int(x)) + ")"
+	}
+}
+
+func (x Emphasis) GoString() string {
+	switch x {
+	case EmphasisNone:
+		return "mp3.EmphasisNone"
+	case Emphasis50_15:
+		return "mp3.Emphasis50_15"
+	case EmphasisReserved:
+		return "mp3.EmphasisReserved"
+	case EmphasisCCITT_J_17:
+		return "mp3.EmphasisCCITT_J_17"
+	default:
+		return "mp3.Emphasis(" + strconv.Itoa(int(x)) + ")"
+	}
+}
+
+func (i BitrateIndex) GoString() string {
+	if i == BitrateIndexFree {
+		return "mp3.BitrateIndexFree"
+	}
+	return strconv.Itoa(int(i))
+}
+
+func (i BitrateIndex) String() string {
+	if i == BitrateIndexFree {
+		return "free"
+	}
+	return strconv.Itoa(int(i))
+}
+
+// StringFor is like String, but returns the bitrate for the specified version
+// and layer if it is valid.
+func (i BitrateIndex) StringFor(version MPEGVersion, layer MPEGLayer) string {
+	if i != BitrateIndexFree {
+		if bitrate, ok := i.Bitrate(version, layer); ok {
+			return strconv.Itoa(bitrate) + "-kbit/s"
+		}
+	}
+	return i.String()
+}
+
+func (i SamplingFrequencyIndex) String() string {
+	return strconv.Itoa(int(i))
+}
+
+// StringFor is like String, but returns the sampling frequency for the
+// specified version if it is valid.
+func (i SamplingFrequencyIndex) StringFor(version MPEGVersion) string {
+	if freq, ok := i.SamplingFrequency(version); ok {
+		return strconv.Itoa(freq) + "-Hz"
+	}
+	return i.String()
+}
+
+func (m ModeExtension) String() string {
+	return strconv.Itoa(int(m))
+}
+
+// StringFor is like String, but returns the bound (for [MPEGLayerI] and
+// [MPEGLayerII]) or the joint stereo coding (for [MPEGLayerIII]).
+func (m ModeExtension) StringFor(layer MPEGLayer) string {
+	switch layer {
+	case MPEGLayerI, MPEGLayerII:
+		if bound, ok := m.Bound(); ok {
+			return "bound=" + strconv.Itoa(bound)
+		}
+	case MPEGLayerIII:
+		if intensityStereo, msStereo, ok := m.Coding(); ok {
+			switch {
+			case intensityStereo && msStereo:
+				return "intensity-stereo+ms-stereo"
+			case intensityStereo:
+				return "intensity-stereo"
+			case msStereo:
+				return "ms-stereo"
+			default:
+				return "none"
+			}
+		}
+	}
+	return m.String()
+}
+
+func (x MPEGVersion) MarshalText() ([]byte, error) { return []byte(x.String()), nil }
+func (x MPEGLayer) MarshalText() ([]byte, error)   { return []byte(x.String()), nil }
+func (x Mode) MarshalText() ([]byte, error)        { return []byte(x.String()), nil }
+func (x Emphasis) MarshalText() ([]byte, error)    { return []byte(x.String()), nil }
+
+func (x *MPEGVersion) UnmarshalText(b []byte) error { return unmarshalEnumText(b, x, "mpeg version") }
+func (x *MPEGLayer) UnmarshalText(b []byte) error   { return unmarshalEnumText(b, x, "mpeg layer") }
+func (x *Mode) UnmarshalText(b []byte) error        { return unmarshalEnumText(b, x, "mode") }
+func (x *Emphasis) UnmarshalText(b []byte) error    { return unmarshalEnumText(b, x, "emphasis") }
+
+// unmarshalEnumText sets v to the 2-bit enum value with a String matching b.
+func unmarshalEnumText[T interface {
+	~uint8
+	String() string
+}](b []byte, v *T, what string) error {
+	for x := T(0); x <= 0b11; x++ {
+		if x.String() == string(b) {
+			*v = x
+			return nil
+		}
+	}
+	return errors.New("unknown " + what + " " + strconv.Quote(string(b)))
+}
+
 func (f FrameHeader) String() string {
 	var b strings.Builder
 	b.WriteString("frame(")
@@ -485,15 +1154,15 @@ func (f FrameHeader) String() string {
 				if intensityStereo && msStereo {
 					b.WriteByte('+')
 				}
-				if intensityStereo {
+				if msStereo {
 					b.WriteString("ms-stereo")
 				}
+				if !intensityStereo && !msStereo {
+					b.WriteString("none")
+				}
 			}
 		default:
 			b.WriteString("?")
-		}
-		if f.Layer == MPEGLayerIII {
-
 		}
 		b.WriteString(")")
 	}
@@ -526,3 +1195,34 @@ func (f FrameHeader) String() string {
 	b.WriteString("}")
 	return b.String()
 }
+
+// GoString returns f as a Go composite literal using the named constants.
+func (f FrameHeader) GoString() string {
+	var b strings.Builder
+	b.WriteString("mp3.FrameHeader{ID: ")
+	b.WriteString(f.ID.GoString())
+	b.WriteString(", Layer: ")
+	b.WriteString(f.Layer.GoString())
+	b.WriteString(", Protection: ")
+	b.WriteString(strconv.FormatBool(f.Protection))
+	b.WriteString(", BitrateIndex: ")
+	b.WriteString(f.BitrateIndex.GoString())
+	b.WriteString(", SamplingFrequencyIndex: ")
+	b.WriteString(strconv.Itoa(int(f.SamplingFrequencyIndex)))
+	b.WriteString(", Padding: ")
+	b.WriteString(strconv.FormatBool(f.Padding))
+	b.WriteString(", Private: ")
+	b.WriteString(strconv.FormatBool(f.Private))
+	b.WriteString(", Mode: ")
+	b.WriteString(f.Mode.GoString())
+	b.WriteString(", ModeExtension: ")
+	b.WriteString(strconv.Itoa(int(f.ModeExtension)))
+	b.WriteString(", Copyright: ")
+	b.WriteString(strconv.FormatBool(f.Copyright))
+	b.WriteString(", Original: ")
+	b.WriteString(strconv.FormatBool(f.Original))
+	b.WriteString(", Emphasis: ")
+	b.WriteString(f.Emphasis.GoString())
+	b.WriteString("}")
+	return b.String()
+}
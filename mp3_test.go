@@ -3,10 +3,18 @@ package mp3
 import (
 	"bytes"
 	"embed"
+	"encoding"
+	"encoding/binary"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
+	"maps"
+	"math"
+	"math/rand/v2"
 	"path"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -19,8 +27,8 @@ var (
 	VerboseFrame = flag.Bool("mp3.vframe", false, "log frame info in some tests")
 )
 
-func TestRoundtrip(t *testing.T) {
-	t.Parallel()
+// testStreams runs fn as a subtest for each test stream.
+func testStreams(t *testing.T, fn func(t *testing.T, buf []byte)) {
 	if err := fs.WalkDir(testdata, "testdata", func(p string, d fs.DirEntry, err error) error {
 		if d.IsDir() {
 			return nil
@@ -32,7 +40,7 @@ func TestRoundtrip(t *testing.T) {
 				return err
 			}
 			t.Run(p[len("testdata/"):len(p)-len(".xxx")], func(t *testing.T) {
-				testRoundtrip(t, buf)
+				fn(t, buf)
 			})
 		}
 		return nil
@@ -41,10 +49,21 @@ func TestRoundtrip(t *testing.T) {
 	}
 }
 
-func testRoundtrip(t *testing.T, buf []byte) {
-	if strings.HasSuffix(t.Name(), "/layer3/he_free") {
-		t.SkipNow() // not implemented yet
+// sameError returns true if a and b are both nil, or have the same message
+// (since errors like [ErrTruncatedFrame] are not comparable).
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
 	}
+	return a.Error() == b.Error()
+}
+
+func TestRoundtrip(t *testing.T) {
+	t.Parallel()
+	testStreams(t, testRoundtrip)
+}
+
+func testRoundtrip(t *testing.T, buf []byte) {
 	r := NewReader(bytes.NewReader(buf), 16384)
 	n := 0         // frame number
 	o := Sync(buf) // expected offset
@@ -54,6 +73,9 @@ func testRoundtrip(t *testing.T, buf []byte) {
 		if *VerboseFrame {
 			t.Logf("read [% 4d] % 6d + % 4d (%.3fs) :: %s\n", n, r.Offset()-int64(len(r.Raw())), len(r.Raw()), ts.Seconds(), r.Header())
 		}
+		if d, ok := r.Header().Duration(); !ok || r.Time()-ts != d {
+			t.Errorf("frame %d: expected duration %s, got %s", n, r.Time()-ts, d)
+		}
 		ts = r.Time()
 
 		o += len(r.Raw())
@@ -61,6 +83,23 @@ func testRoundtrip(t *testing.T, buf []byte) {
 			t.Errorf("frame %d: frames are not back-to-back after first syncword", n)
 		}
 
+		sz := FrameHeaderSize + len(r.Data())
+		if r.Header().Protection {
+			sz += 2
+		}
+		if padding, ok := r.Padding(); ok {
+			sz += len(padding)
+		}
+		if sz != len(r.Raw()) {
+			t.Errorf("frame %d: data length inconsistent with raw frame", n)
+		}
+
+		if sz, ok := r.Header().FrameSize(); ok && sz != len(r.Raw()) {
+			t.Errorf("frame %d: expected frame size %d, got %d", n, len(r.Raw()), sz)
+		} else if !ok && r.Header().BitrateIndex != BitrateIndexFree {
+			t.Errorf("frame %d: failed to get frame size", n)
+		}
+
 		buf, _ := r.Header().AppendBinary(nil)
 		buf = append(buf, r.Raw()[FrameHeaderSize:]...)
 		if !bytes.Equal(r.Raw(), buf) {
@@ -73,23 +112,19 @@ func testRoundtrip(t *testing.T, buf []byte) {
 		t.Logf("read %d frames (%s)", n, ts)
 	}
 	err := r.Err()
-	if err != nil {
-		err = fmt.Errorf("frame %d (offset %d): %w", n+1, r.Offset(), err)
-	}
+	var truncated int // frame number
 	switch {
 	case strings.HasSuffix(t.Name(), "/layer3/compl"):
-		if exp := "frame 217 (offset 41472): unexpected EOF"; err == nil || err.Error() != exp {
-			t.Errorf("expected error %v, got %v", exp, err)
-		}
-		return
+		truncated = 217
 	case strings.HasSuffix(t.Name(), "/layer3/sin1k0db"): // TODO: is this error expected?
-		if exp := "frame 318 (offset 132708): unexpected EOF"; err == nil || err.Error() != exp {
-			t.Errorf("expected error %v, got %v", exp, err)
-		}
-		return
+		truncated = 318
 	case strings.HasSuffix(t.Name(), "/mpeg2/test23"):
-		if exp := "frame 342 (offset 327360): unexpected EOF"; err == nil || err.Error() != exp {
-			t.Errorf("expected error %v, got %v", exp, err)
+		truncated = 342
+	}
+	if truncated != 0 {
+		var terr *ErrTruncatedFrame
+		if !errors.As(err, &terr) || !errors.Is(err, io.ErrUnexpectedEOF) || n+1 != truncated || terr.Offset != r.Offset() || terr.Available >= terr.Size {
+			t.Errorf("expected frame %d (offset %d) to be truncated, got %v", n+1, r.Offset(), err)
 		}
 		return
 	}
@@ -102,7 +137,1416 @@ func testRoundtrip(t *testing.T, buf []byte) {
 		if act, exp := r.Time().Truncate(time.Millisecond).String(), "3.6s"; act != exp {
 			t.Errorf("expected duration %s, got %s", act, exp)
 		}
+		if act, exp := r.SamplePosition(), int64(48000*3.6); act != exp {
+			t.Errorf("expected sample position %d, got %d", exp, act)
+		}
 	}
 
 	// TODO: test writing back
 }
+
+func TestErrorCheck(t *testing.T) {
+	t.Parallel()
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var n, checked int
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			n++
+			exp, ok := r.ErrorCheck()
+			if !ok {
+				continue
+			}
+			act, ok := ComputeErrorCheck(*r.Header(), r.Raw()[FrameHeaderSize+2:])
+			if !ok {
+				if r.Header().BitrateIndex != BitrateIndexFree {
+					t.Errorf("frame %d: failed to compute crc", n)
+				}
+				continue
+			}
+			if act != exp {
+				t.Errorf("frame %d: expected crc %04x, got %04x", n, exp, act)
+			}
+			checked++
+		}
+		if checked != 0 {
+			t.Logf("checked %d/%d frames", checked, n)
+		}
+	})
+}
+
+func TestProtectedRange(t *testing.T) {
+	for _, tc := range []struct {
+		Header     FrameHeader
+		Start, End int
+		OK         bool
+	}{
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerI, Mode: ModeSingleChannel}, 0, 0, false},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerI, Protection: true, Mode: ModeSingleChannel}, 6, 22, true},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerI, Protection: true, Mode: ModeStereo}, 6, 38, true},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerI, Protection: true, Mode: ModeJointStereo, ModeExtension: 0b00}, 6, 24, true},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, Protection: true, Mode: ModeStereo}, 0, 0, false},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, Protection: true, Mode: ModeSingleChannel}, 6, 23, true},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, Protection: true, Mode: ModeJointStereo}, 6, 38, true},
+		{FrameHeader{ID: MPEGVersion2, Layer: MPEGLayerIII, Protection: true, Mode: ModeSingleChannel}, 6, 15, true},
+		{FrameHeader{ID: MPEGVersion2_5, Layer: MPEGLayerIII, Protection: true, Mode: ModeDualChannel}, 6, 23, true},
+	} {
+		start, end, ok := tc.Header.ProtectedRange()
+		if start != tc.Start || end != tc.End || ok != tc.OK {
+			t.Errorf("%s: expected (%d, %d, %t), got (%d, %d, %t)", tc.Header, tc.Start, tc.End, tc.OK, start, end, ok)
+		}
+	}
+}
+
+func TestValidateChecksum(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer1/fl1.mp1")
+	if err != nil {
+		panic(err)
+	}
+	buf = bytes.Clone(buf)
+
+	r := NewReader(bytes.NewReader(buf), 16384)
+	r.ValidateChecksum(true)
+	for r.Next() {
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Reset(bytes.NewReader(buf), 0)
+	r.Next()
+	off := r.Offset()
+	buf[off+FrameHeaderSize+2] ^= 0xFF
+
+	r.Reset(bytes.NewReader(buf), 0)
+	for r.Next() {
+	}
+	var cerr *ErrChecksumMismatch
+	if err := r.Err(); !errors.As(err, &cerr) {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if cerr.Offset != off {
+		t.Errorf("expected offset %d, got %d", off, cerr.Offset)
+	}
+	if cerr.Want == cerr.Got {
+		t.Errorf("expected different checksums")
+	}
+}
+
+func TestAppendFrame(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer1/fl1.mp1")
+	if err != nil {
+		panic(err)
+	}
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		h := *r.Header()
+		h.Copyright = !h.Copyright
+
+		frame, err := h.AppendFrame(nil, r.Raw()[FrameHeaderSize:])
+		if err != nil {
+			t.Fatalf("append frame: %v", err)
+		}
+		if len(frame) != len(r.Raw()) {
+			t.Fatalf("expected frame length %d, got %d", len(r.Raw()), len(frame))
+		}
+		exp, _ := ComputeErrorCheck(h, frame[FrameHeaderSize+2:])
+		if act := binary.BigEndian.Uint16(frame[FrameHeaderSize:]); act != exp {
+			t.Errorf("expected crc %04x, got %04x", exp, act)
+		}
+		if act, _ := r.ErrorCheck(); act == exp {
+			t.Errorf("expected crc to change after modifying header")
+		}
+	}
+}
+
+func TestSilentFrame(t *testing.T) {
+	for _, h := range []FrameHeader{
+		{ID: MPEGVersion1, Layer: MPEGLayerI, BitrateIndex: 14, SamplingFrequencyIndex: 2, Mode: ModeJointStereo, ModeExtension: 1, Protection: true},
+		{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 12, Mode: ModeStereo},
+		{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo, Padding: true},
+		{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo, Protection: true},
+		{ID: MPEGVersion2, Layer: MPEGLayerIII, BitrateIndex: 1, SamplingFrequencyIndex: 2, Mode: ModeSingleChannel, Protection: true},
+		{ID: MPEGVersion2_5, Layer: MPEGLayerIII, BitrateIndex: 8, SamplingFrequencyIndex: 1, Mode: ModeStereo},
+	} {
+		frame, err := h.SilentFrame()
+		if err != nil {
+			t.Errorf("%s: %v", h, err)
+			continue
+		}
+		if size, _ := h.FrameSize(); len(frame) != size {
+			t.Errorf("%s: expected %d bytes, got %d", h, size, len(frame))
+		}
+		r := NewReader(bytes.NewReader(slices.Concat(frame, frame)), 16384)
+		r.ValidateChecksum(true)
+		var n int
+		for r.Next() {
+			if *r.Header() != h {
+				t.Errorf("%s: got header %s", h, r.Header())
+			}
+			if h.Layer == MPEGLayerIII {
+				si, err := ParseSideInfoIII(h, r.Data())
+				if err != nil {
+					t.Errorf("%s: parse side info: %v", h, err)
+				} else if si != (SideInfoIII{}) {
+					t.Errorf("%s: expected empty side info, got %+v", h, si)
+				}
+			}
+			n++
+		}
+		if err := r.Err(); err != nil || n != 2 {
+			t.Errorf("%s: expected 2 frames, got %d (err=%v)", h, n, err)
+		}
+	}
+	if _, err := (FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, Mode: ModeStereo}).SilentFrame(); err == nil {
+		t.Errorf("expected error for free format")
+	}
+	if _, err := (FrameHeader{ID: MPEGVersionReserved, Layer: MPEGLayerIII, BitrateIndex: 9}).SilentFrame(); err == nil {
+		t.Errorf("expected error for invalid header")
+	}
+}
+
+func TestSlotsFor(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			slots, padding, ok := r.Header().SlotsFor(len(r.Raw()))
+			if !ok {
+				t.Fatalf("failed to get slots for %s", r.Header())
+			}
+			if padding != r.Header().Padding {
+				t.Errorf("expected padding %t, got %t", r.Header().Padding, padding)
+			}
+			if exp, _, ok := r.Header().Slots(); ok && slots != exp {
+				t.Errorf("expected %d slots, got %d", exp, slots)
+			}
+		}
+	})
+}
+
+func TestPadded(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/sin1k0db.mp3") // 128 kbit/s, 44.1 kHz
+	if err != nil {
+		panic(err)
+	}
+	var (
+		frames, padded int
+		total          int64
+	)
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		slots, truncated, ok := r.Header().Slots()
+		if !ok || !truncated {
+			t.Fatalf("expected truncated slots for %s", r.Header())
+		}
+		if r.Padded() != r.Header().Padding {
+			t.Fatalf("expected padded to match the header")
+		}
+		size := slots
+		if r.Padded() {
+			size++
+			padded++
+		}
+		if size != len(r.Raw()) {
+			t.Fatalf("frame %d: expected %d bytes, got %d", frames, len(r.Raw()), size)
+		}
+		frames++
+		total += int64(size)
+
+		// the padding keeps the total size within a slot of the exact size
+		exact := float64(frames) * 144 * 128000 / 44100
+		if diff := float64(total) - exact; diff < -1 || diff > 1 {
+			t.Fatalf("frame %d: total size %d differs from exact size %f by more than a slot", frames, total, exact)
+		}
+	}
+	if err := r.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) { // the last frame is truncated
+		t.Fatalf("read frames: %v", err)
+	}
+	if NewReader(bytes.NewReader(buf), 16384).Padded() {
+		t.Errorf("expected no padding before the first frame")
+	}
+
+	// 417.96 bytes per frame, so about one in 25 frames is not padded
+	if exp := float64(frames) * (1 - 0.959); math.Abs(float64(frames-padded)-exp) > 1 {
+		t.Errorf("expected about %.1f unpadded frames, got %d of %d", exp, frames-padded, frames)
+	}
+}
+
+func TestLowSamplingFrequency(t *testing.T) {
+	for _, tc := range []struct {
+		Version    MPEGVersion
+		Layer      MPEGLayer
+		Bitrate    int
+		SampleRate int
+		Size       int
+		Truncated  bool
+	}{
+		{MPEGVersion2_5, MPEGLayerIII, 8, 8000, 72, false},
+		{MPEGVersion2_5, MPEGLayerIII, 16, 8000, 144, false},
+		{MPEGVersion2_5, MPEGLayerIII, 32, 11025, 208, true},
+		{MPEGVersion2_5, MPEGLayerIII, 64, 12000, 384, false},
+		{MPEGVersion2_5, MPEGLayerIII, 160, 8000, 1440, false},
+		{MPEGVersion2, MPEGLayerIII, 32, 16000, 144, false},
+		{MPEGVersion2, MPEGLayerIII, 64, 22050, 208, true},
+		{MPEGVersion2_5, MPEGLayerII, 8, 8000, 144, false},
+		{MPEGVersion2_5, MPEGLayerI, 32, 8000, 192, false},
+	} {
+		h, err := NewFrameHeader(tc.Version, tc.Layer, tc.Bitrate, tc.SampleRate, ModeSingleChannel)
+		if err != nil {
+			panic(err)
+		}
+		slotSize, _ := h.SlotSize()
+		if slots, truncated, ok := h.Slots(); !ok || slots*slotSize != tc.Size || truncated != tc.Truncated {
+			t.Errorf("%s: expected %d bytes (truncated=%t), got %d slots (truncated=%t)", h, tc.Size, tc.Truncated, slots, truncated)
+		}
+		if size, ok := h.FrameSize(); !ok || size != tc.Size {
+			t.Errorf("%s: expected frame size %d, got %d", h, tc.Size, size)
+		}
+		h.Padding = true
+		if size, ok := h.FrameSize(); !ok || size != tc.Size+slotSize {
+			t.Errorf("%s: expected padded frame size %d, got %d", h, tc.Size+slotSize, size)
+		}
+		h.Padding = false
+
+		const n = 1000
+		frame, err := h.SilentFrame()
+		if err != nil {
+			panic(err)
+		}
+		r := NewReader(bytes.NewReader(bytes.Repeat(frame, n)), 16384)
+		var frames int
+		for r.Next() {
+			frames++
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("%s: read frames: %v", h, err)
+		}
+		sampleCount, _ := h.SampleCount()
+		if frames != n || r.SamplePosition() != n*int64(sampleCount) {
+			t.Errorf("%s: expected %d frames and %d samples, got %d and %d", h, n, n*sampleCount, frames, r.SamplePosition())
+		}
+		if exp := samplesDuration(n*int64(sampleCount), tc.SampleRate); (r.Time() - exp).Abs() > n {
+			t.Errorf("%s: expected time %s, got %s", h, exp, r.Time())
+		}
+	}
+}
+
+func TestChannelCount(t *testing.T) {
+	for mode, exp := range map[Mode]int{
+		ModeStereo:        2,
+		ModeJointStereo:   2,
+		ModeDualChannel:   2,
+		ModeSingleChannel: 1,
+	} {
+		h := FrameHeader{Mode: mode}
+		if act := h.ChannelCount(); act != exp {
+			t.Errorf("%s: expected %d channels, got %d", mode, exp, act)
+		}
+		if act := h.IsMono(); act != (exp == 1) {
+			t.Errorf("%s: expected mono %t, got %t", mode, exp == 1, act)
+		}
+	}
+}
+
+func TestBound(t *testing.T) {
+	for _, tc := range []struct {
+		Version    MPEGVersion
+		Layer      MPEGLayer
+		Bitrate    int
+		SampleRate int
+		Mode       Mode
+		Extension  ModeExtension
+		Bound      int
+	}{
+		{MPEGVersion1, MPEGLayerI, 384, 44100, ModeStereo, 0, 32},
+		{MPEGVersion1, MPEGLayerI, 384, 44100, ModeJointStereo, 1, 8},
+		{MPEGVersion1, MPEGLayerI, 0, 44100, ModeSingleChannel, 0, 32},
+		{MPEGVersion1, MPEGLayerII, 128, 48000, ModeStereo, 0, 27},
+		{MPEGVersion1, MPEGLayerII, 128, 48000, ModeJointStereo, 3, 16},
+		{MPEGVersion1, MPEGLayerII, 128, 44100, ModeStereo, 0, 27},
+		{MPEGVersion1, MPEGLayerII, 192, 44100, ModeDualChannel, 0, 30},
+		{MPEGVersion1, MPEGLayerII, 192, 48000, ModeStereo, 0, 27},
+		{MPEGVersion1, MPEGLayerII, 64, 48000, ModeJointStereo, 3, 8},
+		{MPEGVersion1, MPEGLayerII, 32, 44100, ModeSingleChannel, 0, 8},
+		{MPEGVersion1, MPEGLayerII, 32, 32000, ModeSingleChannel, 0, 12},
+		{MPEGVersion1, MPEGLayerII, 160, 32000, ModeSingleChannel, 0, 30},
+		{MPEGVersion2, MPEGLayerII, 64, 22050, ModeJointStereo, 3, 16},
+		{MPEGVersion2_5, MPEGLayerII, 8, 8000, ModeSingleChannel, 0, 30},
+		{MPEGVersion1, MPEGLayerII, 0, 44100, ModeStereo, 0, -1},
+		{MPEGVersion1, MPEGLayerIII, 128, 44100, ModeStereo, 0, -1},
+	} {
+		h, err := NewFrameHeader(tc.Version, tc.Layer, tc.Bitrate, tc.SampleRate, tc.Mode)
+		if err != nil {
+			panic(err)
+		}
+		h.ModeExtension = tc.Extension
+		if act, ok := h.Bound(); act != tc.Bound || ok != (tc.Bound != -1) {
+			t.Errorf("%s: expected bound %d, got %d (ok=%t)", h, tc.Bound, act, ok)
+		}
+	}
+	for _, tc := range []struct {
+		Layer         MPEGLayer
+		Mode          Mode
+		Extension     ModeExtension
+		Intensity, MS bool
+		OK            bool
+	}{
+		{MPEGLayerIII, ModeStereo, 3, false, false, true},
+		{MPEGLayerIII, ModeSingleChannel, 0, false, false, true},
+		{MPEGLayerIII, ModeJointStereo, 1, true, false, true},
+		{MPEGLayerIII, ModeJointStereo, 2, false, true, true},
+		{MPEGLayerIII, ModeJointStereo, 3, true, true, true},
+		{MPEGLayerII, ModeJointStereo, 3, false, false, false},
+	} {
+		h := FrameHeader{ID: MPEGVersion1, Layer: tc.Layer, Mode: tc.Mode, ModeExtension: tc.Extension}
+		if is, ms, ok := h.StereoCoding(); is != tc.Intensity || ms != tc.MS || ok != tc.OK {
+			t.Errorf("%s: expected intensity=%t ms=%t ok=%t, got %t %t %t", h, tc.Intensity, tc.MS, tc.OK, is, ms, ok)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		if !r.Next() {
+			t.Fatalf("read first frame: %v", r.Err())
+		}
+		h, i, ok := Probe(buf)
+		if !ok {
+			t.Fatalf("failed to probe")
+		}
+		if exp := int(r.Offset()) - len(r.Raw()); i != exp {
+			t.Errorf("expected first frame at %d, got %d", exp, i)
+		}
+		if h != *r.Header() {
+			t.Errorf("expected header %s, got %s", r.Header(), h)
+		}
+	})
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for range 100 {
+		buf := make([]byte, 8192)
+		for i := range buf {
+			buf[i] = byte(rnd.Uint32())
+		}
+		if _, i, ok := Probe(buf); ok {
+			t.Errorf("unexpected frame at %d in random data", i)
+		}
+	}
+}
+
+func TestStrictSync(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	junk := make([]byte, 1000)
+	copy(junk[100:], "\xFF\xFB\x90\x00") // false syncword
+	buf = append(junk, buf...)
+
+	r := NewReader(bytes.NewReader(buf), 16384)
+	if r.Next(); r.Offset()-int64(len(r.Raw())) != 100 {
+		t.Fatalf("expected non-strict sync to find false syncword")
+	}
+
+	r = NewReader(bytes.NewReader(buf), 16384)
+	r.StrictSync(true)
+	if !r.Next() {
+		t.Fatalf("read first frame: %v", r.Err())
+	}
+	if act, exp := r.Offset()-int64(len(r.Raw())), int64(len(junk)); act != exp {
+		t.Errorf("expected first frame at %d, got %d", exp, act)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+
+	var starts []int64
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		starts = append(starts, r.Offset()-int64(len(r.Raw())))
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+
+	r = NewReader(bytes.NewReader(buf), 16384)
+	for _, tc := range []struct {
+		Offset int64
+		Whence int
+		Frame  int
+	}{
+		{starts[10], io.SeekStart, 10},
+		{starts[10] + 1, io.SeekStart, 11},
+		{starts[5] - starts[12], io.SeekCurrent, 5}, // after reading frame 11
+		{0, io.SeekStart, 0},
+		{starts[len(starts)-1] - int64(len(buf)), io.SeekEnd, len(starts) - 1},
+	} {
+		if _, err := r.Seek(tc.Offset, tc.Whence); err != nil {
+			t.Fatalf("seek %d %d: %v", tc.Offset, tc.Whence, err)
+		}
+		if !r.Next() {
+			t.Fatalf("seek %d %d: read frame: %v", tc.Offset, tc.Whence, r.Err())
+		}
+		if act, exp := r.Offset()-int64(len(r.Raw())), starts[tc.Frame]; act != exp {
+			t.Errorf("seek %d %d: expected frame at %d, got %d", tc.Offset, tc.Whence, exp, act)
+		}
+	}
+	if r.Next() {
+		t.Errorf("expected no frames after the last one")
+	}
+	if err := r.Err(); err != nil {
+		t.Errorf("expected eof, got %v", err)
+	}
+
+	r = NewReader(struct{ io.Reader }{bytes.NewReader(buf)}, 16384)
+	if _, err := r.Seek(0, io.SeekStart); err == nil {
+		t.Errorf("expected error for non-seeker")
+	}
+}
+
+func TestResetOffset(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	buf = slices.Concat(buf, tag)
+
+	var starts []int64
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		starts = append(starts, r.Offset()-int64(len(r.Raw())))
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+
+	k := starts[10]
+	seeked := bytes.NewReader(buf)
+	if _, err := seeked.Seek(k, io.SeekStart); err != nil {
+		panic(err)
+	}
+	for _, tc := range []struct {
+		Name   string
+		Source io.Reader
+	}{
+		{"slice", bytes.NewReader(buf[k:])},
+		{"section", io.NewSectionReader(bytes.NewReader(buf), k, int64(len(buf))-k)},
+		{"seeked", seeked},
+	} {
+		r.Reset(tc.Source, k)
+		var act []int64
+		for r.Next() {
+			act = append(act, r.Offset()-int64(len(r.Raw())))
+		}
+		if err := r.Err(); err != nil {
+			t.Errorf("%s: read frames: %v", tc.Name, err)
+		}
+		if !slices.Equal(act, starts[10:]) {
+			t.Errorf("%s: expected %d frames from offset %d, got %d", tc.Name, len(starts)-10, k, len(act))
+		}
+		if _, ok := r.ID3v1(); !ok {
+			t.Errorf("%s: expected id3v1 tag", tc.Name)
+		}
+	}
+
+	// seeking relative to the current offset
+	for _, src := range []io.Reader{bytes.NewReader(buf[k:]), seeked} {
+		if _, err := seeked.Seek(k, io.SeekStart); err != nil {
+			panic(err)
+		}
+		r.Reset(src, k)
+		if !r.Next() {
+			t.Fatalf("read frame: %v", r.Err())
+		}
+		if _, err := r.Seek(starts[20]-starts[11], io.SeekCurrent); err != nil {
+			t.Fatalf("seek: %v", err)
+		}
+		if !r.Next() {
+			t.Fatalf("read frame after seek: %v", r.Err())
+		}
+		if !bytes.Equal(r.Raw(), buf[starts[20]:starts[21]]) {
+			t.Errorf("expected frame 20 after seeking")
+		}
+	}
+
+	// seeking uses offsets relative to the start of the source
+	r.Reset(bytes.NewReader(buf[k:]), k)
+	if !r.Next() {
+		t.Fatalf("read frame: %v", r.Err())
+	}
+	if _, err := r.Seek(starts[20]-k, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	var n int
+	for r.Next() {
+		n++
+	}
+	if err := r.Err(); err != nil {
+		t.Errorf("read frames after seek: %v", err)
+	}
+	if exp := len(starts) - 20; n != exp {
+		t.Errorf("expected %d frames after seek, got %d", exp, n)
+	}
+}
+
+func TestAverageBitrate(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Bitrates []int
+	}{
+		{"testdata/layer1/fl1.mp1", []int{384}},
+		{"testdata/layer3/he_free.mp3", []int{0}},
+		{"testdata/layer3/he_44khz.mp3", nil},
+	} {
+		buf, err := fs.ReadFile(testdata, tc.Name)
+		if err != nil {
+			panic(err)
+		}
+		r := NewReader(bytes.NewReader(buf), 16384)
+		if _, ok := r.AverageBitrate(); ok {
+			t.Errorf("%s: expected no average bitrate before reading", tc.Name)
+		}
+		var (
+			frames int
+			bits   float64
+		)
+		for r.Next() {
+			frames++
+			bits += float64(len(r.Raw())) * 8
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("%s: read frames: %v", tc.Name, err)
+		}
+		avg, ok := r.AverageBitrate()
+		if exp := bits / r.Time().Seconds() / 1000; !ok || math.Abs(avg-exp) > 0.001 {
+			t.Errorf("%s: expected average bitrate %f, got %f", tc.Name, exp, avg)
+		}
+		hist := r.BitrateHistogram()
+		var n int
+		for _, c := range hist {
+			n += c
+		}
+		if n != frames {
+			t.Errorf("%s: expected %d frames in histogram, got %d", tc.Name, frames, n)
+		}
+		if tc.Bitrates != nil {
+			if act := slices.Sorted(maps.Keys(hist)); !slices.Equal(act, tc.Bitrates) {
+				t.Errorf("%s: expected bitrates %v, got %v", tc.Name, tc.Bitrates, act)
+			}
+		} else if len(hist) < 2 {
+			t.Errorf("%s: expected multiple bitrates, got %v", tc.Name, hist)
+		}
+	}
+}
+
+func TestEffectiveBitrate(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		if _, ok := r.EffectiveBitrate(); ok {
+			t.Errorf("expected no bitrate before reading")
+		}
+		for r.Next() {
+			bitrate, ok := r.EffectiveBitrate()
+			if !ok || bitrate <= 0 {
+				t.Fatalf("%s: expected bitrate, got %d", r.Header(), bitrate)
+			}
+			if r.Header().BitrateIndex != BitrateIndexFree {
+				if exp, _ := r.Header().Bitrate(); bitrate != exp {
+					t.Errorf("%s: expected bitrate %d, got %d", r.Header(), exp, bitrate)
+				}
+			} else {
+				duration, _ := r.Header().Duration()
+				if exp := float64(len(r.Raw())*8) / duration.Seconds() / 1000; math.Abs(float64(bitrate)-exp) > 1 {
+					t.Errorf("%s: expected bitrate about %f, got %d", r.Header(), exp, bitrate)
+				}
+			}
+		}
+	})
+}
+
+func TestRateMode(t *testing.T) {
+	read := func(buf []byte) *Reader {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		if !r.Next() {
+			t.Fatalf("read first frame: %v", r.Err())
+		}
+		if m := r.RateMode(); m != RateUnknown {
+			t.Errorf("expected unknown rate mode before end, got %s", m)
+		}
+		for r.Next() {
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("read frames: %v", err)
+		}
+		return r
+	}
+	for name, exp := range map[string]RateMode{
+		"testdata/layer1/fl1.mp1":      RateCBR,
+		"testdata/layer3/he_free.mp3":  RateCBR,
+		"testdata/layer3/he_44khz.mp3": RateVBR,
+	} {
+		buf, err := fs.ReadFile(testdata, name)
+		if err != nil {
+			panic(err)
+		}
+		if act := read(buf).RateMode(); act != exp {
+			t.Errorf("%s: expected %s, got %s", name, exp, act)
+		}
+	}
+
+	buf, _ := testVBR(false)
+	if act, exp := read(buf).RateMode(), RateVBR; act != exp {
+		t.Errorf("xing: expected %s, got %s", exp, act)
+	}
+
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	lame := slices.Clone(testLAME)
+	lame[9] = 0x12 // abr
+	buf = testInfoFrame(h, XingHeader{Tag: "Xing"}, lame)
+	for _, i := range []BitrateIndex{9, 10, 9, 8} {
+		g := h
+		g.BitrateIndex = i
+		size, _ := g.FrameSize()
+		frame := make([]byte, size)
+		g.encode(frame)
+		buf = append(buf, frame...)
+	}
+	if act, exp := read(buf).RateMode(), RateABR; act != exp {
+		t.Errorf("lame: expected %s, got %s", exp, act)
+	}
+	size, _ := h.FrameSize()
+	if act, exp := read(buf[size:]).RateMode(), RateABR; act != exp {
+		t.Errorf("no header: expected %s, got %s", exp, act)
+	}
+}
+
+func TestReaderEmphasis(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/hecommon.mp3")
+	if err != nil {
+		panic(err)
+	}
+	seen := map[Emphasis]bool{}
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		if act, exp := r.Emphasis(), Emphasis(r.Raw()[3]&0b11); act != exp {
+			t.Errorf("expected emphasis %s, got %s", exp, act)
+		}
+		seen[r.Emphasis()] = true
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected all emphasis values, got %v", seen)
+	}
+}
+
+func TestFormatChanged(t *testing.T) {
+	var (
+		buf []byte
+		exp []bool
+	)
+	for i, tc := range []struct {
+		SampleRate int
+		Mode       Mode
+		Bitrate    int
+	}{
+		{44100, ModeStereo, 128},
+		{44100, ModeStereo, 192},
+		{44100, ModeJointStereo, 128},
+		{48000, ModeJointStereo, 128},
+		{48000, ModeJointStereo, 128},
+		{48000, ModeSingleChannel, 64},
+		{44100, ModeSingleChannel, 64},
+	} {
+		h, err := NewFrameHeader(MPEGVersion1, MPEGLayerIII, tc.Bitrate, tc.SampleRate, tc.Mode)
+		if err != nil {
+			panic(err)
+		}
+		frame, err := h.SilentFrame()
+		if err != nil {
+			panic(err)
+		}
+		buf = append(buf, frame...)
+		exp = append(exp, i == 3 || i == 5 || i == 6)
+	}
+	r := NewReader(bytes.NewReader(buf), 16384)
+	if r.FormatChanged() {
+		t.Errorf("expected no format change before reading")
+	}
+	var act []bool
+	for r.Next() {
+		act = append(act, r.FormatChanged())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if !slices.Equal(act, exp) {
+		t.Errorf("expected format changes %v, got %v", exp, act)
+	}
+	r.Reset(bytes.NewReader(buf[len(buf)-len(r.Raw()):]), 0)
+	if !r.Next() || r.FormatChanged() {
+		t.Errorf("expected no format change for the first frame after reset")
+	}
+}
+
+func TestLeadingJunk(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	junk := make([]byte, 100000)
+	for i := range junk {
+		junk[i] = byte(i % 0xFF) // no syncwords
+	}
+
+	r := NewReader(bytes.NewReader(append(junk, buf...)), 4096)
+	if !r.Next() {
+		t.Fatalf("read first frame: %v", r.Err())
+	}
+	if act, exp := r.Offset()-int64(len(r.Raw())), int64(len(junk)); act != exp {
+		t.Errorf("expected first frame at %d, got %d", exp, act)
+	}
+
+	r = NewReader(bytes.NewReader(junk), 4096)
+	if r.Next() || r.Err() != ErrUnsynchronized {
+		t.Errorf("expected %v for junk, got %v", ErrUnsynchronized, r.Err())
+	}
+
+	for i := 0; i < len(junk)-4; i += 1000 {
+		copy(junk[i:], "\xFF\xFB\x90\x00") // false syncwords
+	}
+	r = NewReader(bytes.NewReader(append(junk, buf...)), 4096)
+	r.StrictSync(true)
+	if !r.Next() {
+		t.Fatalf("strict: read first frame: %v", r.Err())
+	}
+	if act, exp := r.Offset()-int64(len(r.Raw())), int64(len(junk)); act != exp {
+		t.Errorf("strict: expected first frame at %d, got %d", exp, act)
+	}
+}
+
+func TestSyncBoundary(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	const size = 4096
+	for _, n := range []int{size - 2, size - 1, size, 2*size - 3, 2*size - 2, 2*size - 1} {
+		junk := make([]byte, n)
+		for i := range junk {
+			junk[i] = byte(i % 0xFF) // no syncwords
+		}
+		junk[n-1] = 0 // ensure the syncword is not preceded by 0xFF
+
+		// first syncword
+		r := NewReader(bytes.NewReader(append(junk, buf...)), size)
+		if !r.Next() {
+			t.Fatalf("%d: read first frame: %v", n, r.Err())
+		}
+		if act, exp := r.Offset()-int64(len(r.Raw())), int64(n); act != exp {
+			t.Errorf("%d: expected first frame at %d, got %d", n, exp, act)
+		}
+
+		// resync after an invalid frame
+		bad := []byte{0xFF, 0xFF, 0xFF, 0xFF} // invalid bitrate
+		r = NewReader(bytes.NewReader(slices.Concat(bad, junk, buf)), size)
+		r.SkipErrors(true)
+		if !r.Next() {
+			t.Fatalf("%d: resync: read first frame: %v", n, r.Err())
+		}
+		if act, exp := r.Offset()-int64(len(r.Raw())), int64(len(bad)+n); act != exp {
+			t.Errorf("%d: resync: expected first frame at %d, got %d", n, exp, act)
+		}
+	}
+}
+
+func TestSkipErrors(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+
+	var n int
+	var offsets []int64
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		n++
+		offsets = append(offsets, r.Offset())
+	}
+
+	junk := bytes.Repeat([]byte{0xFF}, 37)
+	at := offsets[10]
+	buf = slices.Concat(buf[:at], junk, buf[at:])
+
+	r = NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+	}
+	if r.Err() == nil {
+		t.Fatalf("expected error without skip mode")
+	}
+
+	var nt int
+	var ranges [][2]int64
+	r = NewReader(bytes.NewReader(buf), 16384)
+	r.SkipErrors(true)
+	r.OnResync(func(from, to int64) {
+		ranges = append(ranges, [2]int64{from, to})
+	})
+	for r.Next() {
+		nt++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	if nt != n {
+		t.Errorf("expected %d frames, got %d", n, nt)
+	}
+	if act, exp := r.Skipped(), int64(len(junk)); act != exp {
+		t.Errorf("expected %d bytes to be skipped, got %d", exp, act)
+	}
+	if exp := [][2]int64{{at, at + int64(len(junk))}}; !slices.Equal(ranges, exp) {
+		t.Errorf("expected skipped ranges %v, got %v", exp, ranges)
+	}
+}
+
+func TestFrameHeaderStringCoding(t *testing.T) {
+	for ext, exp := range []string{
+		"(none)",
+		"(intensity-stereo)",
+		"(ms-stereo)",
+		"(intensity-stereo+ms-stereo)",
+	} {
+		h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo, ModeExtension: ModeExtension(ext)}
+		if act := h.String(); !strings.Contains(act, ",joint-stereo,"+exp+",") {
+			t.Errorf("mode extension %d: expected %q in %q", ext, exp, act)
+		}
+	}
+}
+
+func TestFrameHeaderGoString(t *testing.T) {
+	for h, exp := range map[FrameHeader]string{
+		{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, SamplingFrequencyIndex: 1, Padding: true, Mode: ModeJointStereo, ModeExtension: 2, Original: true}: "mp3.FrameHeader{ID: mp3.MPEGVersion1, Layer: mp3.MPEGLayerIII, Protection: false, BitrateIndex: 9, SamplingFrequencyIndex: 1, Padding: true, Private: false, Mode: mp3.ModeJointStereo, ModeExtension: 2, Copyright: false, Original: true, Emphasis: mp3.EmphasisNone}",
+		{ID: MPEGVersion2_5, Layer: MPEGLayerI, Protection: true, Mode: ModeSingleChannel, Emphasis: EmphasisCCITT_J_17}:                                            "mp3.FrameHeader{ID: mp3.MPEGVersion2_5, Layer: mp3.MPEGLayerI, Protection: true, BitrateIndex: mp3.BitrateIndexFree, SamplingFrequencyIndex: 0, Padding: false, Private: false, Mode: mp3.ModeSingleChannel, ModeExtension: 0, Copyright: false, Original: false, Emphasis: mp3.EmphasisCCITT_J_17}",
+		{ID: MPEGVersionReserved, Layer: 7, Mode: 4, Emphasis: EmphasisReserved}:                                                                                    "mp3.FrameHeader{ID: mp3.MPEGVersionReserved, Layer: mp3.MPEGLayer(7), Protection: false, BitrateIndex: mp3.BitrateIndexFree, SamplingFrequencyIndex: 0, Padding: false, Private: false, Mode: mp3.Mode(4), ModeExtension: 0, Copyright: false, Original: false, Emphasis: mp3.EmphasisReserved}",
+	} {
+		if act := fmt.Sprintf("%#v", h); act != exp {
+			t.Errorf("expected %s, got %s", exp, act)
+		}
+	}
+}
+
+func TestIndexString(t *testing.T) {
+	for _, tc := range []struct {
+		Act, Exp string
+	}{
+		{BitrateIndex(0).String(), "free"},
+		{BitrateIndex(9).String(), "9"},
+		{BitrateIndex(0).StringFor(MPEGVersion1, MPEGLayerIII), "free"},
+		{BitrateIndex(9).StringFor(MPEGVersion1, MPEGLayerIII), "128-kbit/s"},
+		{BitrateIndex(9).StringFor(MPEGVersion2, MPEGLayerIII), "80-kbit/s"},
+		{BitrateIndex(15).StringFor(MPEGVersion1, MPEGLayerIII), "15"},
+		{BitrateIndex(9).StringFor(MPEGVersionReserved, MPEGLayerIII), "9"},
+		{SamplingFrequencyIndex(1).String(), "1"},
+		{SamplingFrequencyIndex(1).StringFor(MPEGVersion1), "48000-Hz"},
+		{SamplingFrequencyIndex(1).StringFor(MPEGVersion2_5), "12000-Hz"},
+		{SamplingFrequencyIndex(3).StringFor(MPEGVersion1), "3"},
+		{ModeExtension(2).String(), "2"},
+		{ModeExtension(2).StringFor(MPEGLayerI), "bound=12"},
+		{ModeExtension(2).StringFor(MPEGLayerII), "bound=12"},
+		{ModeExtension(2).StringFor(MPEGLayerIII), "ms-stereo"},
+		{ModeExtension(2).StringFor(MPEGLayerReserved), "2"},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, Mode: ModeSingleChannel}.String(), "frame(){mpeg-1,layer-3,single-channel,44100-Hz,0-kbit/s}"},
+	} {
+		if tc.Act != tc.Exp {
+			t.Errorf("expected %q, got %q", tc.Exp, tc.Act)
+		}
+	}
+}
+
+func TestErrorCheckShort(t *testing.T) {
+	r := NewReader(bytes.NewReader(nil), 16384)
+	r.header = FrameHeader{Protection: true}
+	r.data = []byte{0xFF, 0xFA, 0x00, 0x00, 0x00}
+	if _, ok := r.ErrorCheck(); ok {
+		t.Errorf("expected no error check for short frame")
+	}
+}
+
+// TestErrorCheckProtected checks that the error check is computed correctly for
+// every frame of the protected Layer I and II conformance streams, which
+// together cover each mode (including joint stereo with each bound) for both
+// MPEG-1 and the lower sampling frequencies.
+func TestErrorCheckProtected(t *testing.T) {
+	covered := map[[3]string]bool{}
+	for _, name := range []string{
+		"testdata/layer1/fl1.mp1",   // stereo, joint stereo (bound 4-16)
+		"testdata/layer1/fl5.mp1",   // dual channel
+		"testdata/layer2/fl10.mp2",  // stereo, joint stereo (bound 4-16)
+		"testdata/layer2/fl14.mp2",  // dual channel
+		"testdata/layer2/fl15.mp2",  // stereo, all allocations
+		"testdata/mpeg2/test24.mpg", // lsf stereo, joint stereo
+		"testdata/mpeg2/test30.mpg", // lsf single channel
+		"testdata/mpeg2/test31.mpg", // lsf dual channel
+		"testdata/mpeg2/test33.mpg", // lsf layer 1 stereo, joint stereo
+	} {
+		buf, err := fs.ReadFile(testdata, name)
+		if err != nil {
+			panic(err)
+		}
+		var n int
+		r := NewReader(bytes.NewReader(buf), 16384)
+		r.ValidateChecksum(true)
+		for ; r.Next(); n++ {
+			h := r.Header()
+			if !h.Protection {
+				t.Errorf("%s: frame %d: expected protected frame", name, n)
+				continue
+			}
+			if _, ok := ComputeErrorCheck(*h, r.Raw()[FrameHeaderSize+2:]); !ok {
+				t.Errorf("%s: frame %d: failed to compute crc", name, n)
+			}
+			covered[[3]string{h.ID.String(), h.Layer.String(), h.Mode.String()}] = true
+		}
+		if err := r.Err(); err != nil {
+			t.Errorf("%s: read frames: %v", name, err)
+		}
+	}
+	for _, id := range []MPEGVersion{MPEGVersion1, MPEGVersion2} {
+		for _, mode := range []Mode{ModeStereo, ModeJointStereo, ModeDualChannel} {
+			if !covered[[3]string{id.String(), MPEGLayerII.String(), mode.String()}] {
+				t.Errorf("expected %s %s %s to be covered", id, MPEGLayerII, mode)
+			}
+		}
+	}
+	if !covered[[3]string{MPEGVersion2.String(), MPEGLayerII.String(), ModeSingleChannel.String()}] {
+		t.Errorf("expected %s %s %s to be covered", MPEGVersion2, MPEGLayerII, ModeSingleChannel)
+	}
+}
+
+func TestErrorCheckLayerII(t *testing.T) {
+	for _, tc := range []struct {
+		Name         string
+		Header       FrameHeader
+		Alloc, SCFSI int // bits, with all subbands allocated
+	}{
+		// 27 subbands (table a), 2 channels
+		{"stereo", FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, Protection: true, BitrateIndex: 12, SamplingFrequencyIndex: 1, Mode: ModeStereo}, 2 * (11*4 + 12*3 + 4*2), 2 * 27 * 2},
+		// bound 4, so 4 subbands with 2 channels and 23 shared
+		{"joint stereo", FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, Protection: true, BitrateIndex: 12, SamplingFrequencyIndex: 1, Mode: ModeJointStereo}, 2*4*4 + 7*4 + 12*3 + 4*2, 2 * 27 * 2},
+		// 8 subbands (table c), 1 channel
+		{"mono", FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, Protection: true, BitrateIndex: 1, SamplingFrequencyIndex: 1, Mode: ModeSingleChannel}, 2*4 + 6*3, 8 * 2},
+		// 30 subbands (lsf table), 1 channel
+		{"lsf", FrameHeader{ID: MPEGVersion2, Layer: MPEGLayerII, Protection: true, BitrateIndex: 8, SamplingFrequencyIndex: 1, Mode: ModeSingleChannel}, 4*4 + 7*3 + 19*2, 30 * 2},
+	} {
+		data := bytes.Repeat([]byte{0xFF}, 64)
+		if n, ok := protectedBitsLayerII(tc.Header, data); !ok || n != tc.Alloc+tc.SCFSI {
+			t.Errorf("%s: expected %d bits, got %d (ok=%t)", tc.Name, tc.Alloc+tc.SCFSI, n, ok)
+		}
+		if _, ok := ComputeErrorCheck(tc.Header, data[:(tc.Alloc+tc.SCFSI)/8-1]); ok {
+			t.Errorf("%s: expected no error check for short data", tc.Name)
+		}
+		if n, ok := protectedBitsLayerII(tc.Header, make([]byte, 64)); !ok || n != tc.Alloc {
+			t.Errorf("%s: expected %d bits without any allocation, got %d (ok=%t)", tc.Name, tc.Alloc, n, ok)
+		}
+	}
+	if _, ok := ComputeErrorCheck(FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, Protection: true, Mode: ModeStereo}, make([]byte, 64)); ok {
+		t.Errorf("expected no error check for free format")
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	for _, name := range []string{
+		"testdata/layer1/fl1.mp1",
+		"testdata/layer2/fl10.mp2",
+		"testdata/layer3/he_free.mp3",
+		"testdata/layer3/he_mode.mp3",
+		"testdata/mpeg2/test33.mpg",
+	} {
+		buf, err := fs.ReadFile(testdata, name)
+		if err != nil {
+			panic(err)
+		}
+		f.Add(byte(0), buf[:min(len(buf), 1024)])
+	}
+	f.Fuzz(func(t *testing.T, opts byte, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 2048)
+		r.ValidateChecksum(opts&1 != 0)
+		r.StrictSync(opts&2 != 0)
+		r.SkipErrors(opts&4 != 0)
+		for r.Next() {
+			r.Frame()
+			r.Data()
+			r.Padding()
+			r.ErrorCheck()
+			r.MainDataBegin()
+			r.LogicalMainData()
+			r.IsInfoFrame()
+			_ = r.Header().String()
+		}
+	})
+}
+
+func TestNewReaderSize(t *testing.T) {
+	if _, err := NewReaderSize(bytes.NewReader(nil), FrameHeaderSize); err == nil {
+		t.Errorf("expected error for invalid buffer size")
+	}
+	if r, err := NewReaderSize(bytes.NewReader(nil), 16384); err != nil || r == nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValid(t *testing.T) {
+	valid := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	if err := valid.Valid(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		Modify func(*FrameHeader)
+		Err    error
+	}{
+		{func(h *FrameHeader) { h.ID = MPEGVersionReserved }, ErrReservedVersion},
+		{func(h *FrameHeader) { h.ID = 0b100 }, ErrInvalidVersion},
+		{func(h *FrameHeader) { h.Layer = MPEGLayerReserved }, ErrReservedLayer},
+		{func(h *FrameHeader) { h.Layer = 0b100 }, ErrInvalidLayer},
+		{func(h *FrameHeader) { h.BitrateIndex = 0b1111 }, ErrInvalidBitrate},
+		{func(h *FrameHeader) { h.SamplingFrequencyIndex = 0b11 }, ErrInvalidSampleRate},
+		{func(h *FrameHeader) { h.Mode = 0b100 }, ErrInvalidMode},
+		{func(h *FrameHeader) { h.Emphasis = EmphasisReserved }, ErrReservedEmphasis},
+		{func(h *FrameHeader) { h.Emphasis = 0b100 }, ErrInvalidEmphasis},
+	} {
+		h := valid
+		tc.Modify(&h)
+		if err := h.Valid(); !errors.Is(err, tc.Err) {
+			t.Errorf("expected error %v, got %v", tc.Err, err)
+		}
+	}
+
+	for reserved, invalid := range map[error]error{
+		ErrReservedVersion:  ErrInvalidVersion,
+		ErrReservedLayer:    ErrInvalidLayer,
+		ErrReservedEmphasis: ErrInvalidEmphasis,
+	} {
+		if !errors.Is(reserved, invalid) {
+			t.Errorf("expected %v to match %v", reserved, invalid)
+		}
+	}
+	if !MPEGVersionReserved.Reserved() || MPEGVersion1.Reserved() {
+		t.Errorf("incorrect MPEGVersion.Reserved")
+	}
+	if !MPEGLayerReserved.Reserved() || MPEGLayerIII.Reserved() {
+		t.Errorf("incorrect MPEGLayer.Reserved")
+	}
+	if !EmphasisReserved.Reserved() || EmphasisNone.Reserved() {
+		t.Errorf("incorrect Emphasis.Reserved")
+	}
+
+	for _, tc := range []struct {
+		Buf []byte
+		Err error
+	}{
+		{[]byte{0xFF, 0xFB, 0xF0, 0x00, 0x00, 0x00, 0x00, 0x00}, ErrInvalidBitrate},
+		{[]byte{0xFF, 0xEB, 0x90, 0x00, 0x00, 0x00, 0x00, 0x00}, ErrReservedVersion},
+		{[]byte{0xFF, 0xF9, 0x90, 0x00, 0x00, 0x00, 0x00, 0x00}, ErrReservedLayer},
+	} {
+		r := NewReader(bytes.NewReader(tc.Buf), 16384)
+		if r.Next(); !errors.Is(r.Err(), tc.Err) {
+			t.Errorf("%x: expected error %v, got %v", tc.Buf, tc.Err, r.Err())
+		}
+	}
+}
+
+func TestValidStrict(t *testing.T) {
+	for _, tc := range []struct {
+		Header FrameHeader
+		Err    error
+	}{
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 1, Mode: ModeSingleChannel}, nil},                // 32
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 1, Mode: ModeStereo}, ErrInvalidBitrate},         // 32
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 5, Mode: ModeJointStereo}, ErrInvalidBitrate},    // 80
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 6, Mode: ModeSingleChannel}, nil},                // 96
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 6, Mode: ModeDualChannel}, nil},                  // 96
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 11, Mode: ModeSingleChannel}, ErrInvalidBitrate}, // 224
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 14, Mode: ModeStereo}, nil},                      // 384
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: BitrateIndexFree, Mode: ModeStereo}, nil},
+		{FrameHeader{ID: MPEGVersion2, Layer: MPEGLayerII, BitrateIndex: 1, Mode: ModeStereo}, nil},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 1, Mode: ModeStereo}, nil},
+		{FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerII, BitrateIndex: 0b1111, Mode: ModeStereo}, ErrInvalidBitrate},
+		{FrameHeader{ID: MPEGVersionReserved, Layer: MPEGLayerII, BitrateIndex: 1, Mode: ModeStereo}, ErrReservedVersion},
+	} {
+		if err := tc.Header.ValidStrict(); !errors.Is(err, tc.Err) || (err == nil) != (tc.Err == nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.Header, tc.Err, err)
+		}
+	}
+	testStreams(t, func(t *testing.T, buf []byte) {
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			if err := r.Header().ValidStrict(); err != nil && !errors.Is(err, ErrReservedEmphasis) {
+				t.Fatalf("%s: unexpected error: %v", r.Header(), err)
+			}
+		}
+	})
+}
+
+func TestNewFrameHeader(t *testing.T) {
+	for _, tc := range []struct {
+		Version    MPEGVersion
+		Layer      MPEGLayer
+		Bitrate    int
+		SampleRate int
+		Mode       Mode
+		Header     FrameHeader
+		Err        error
+	}{
+		{MPEGVersion1, MPEGLayerIII, 128, 44100, ModeJointStereo, FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, SamplingFrequencyIndex: 0, Mode: ModeJointStereo}, nil},
+		{MPEGVersion2, MPEGLayerIII, 8, 16000, ModeSingleChannel, FrameHeader{ID: MPEGVersion2, Layer: MPEGLayerIII, BitrateIndex: 1, SamplingFrequencyIndex: 2, Mode: ModeSingleChannel}, nil},
+		{MPEGVersion2_5, MPEGLayerII, 160, 12000, ModeStereo, FrameHeader{ID: MPEGVersion2_5, Layer: MPEGLayerII, BitrateIndex: 14, SamplingFrequencyIndex: 1, Mode: ModeStereo}, nil},
+		{MPEGVersion1, MPEGLayerI, 448, 32000, ModeDualChannel, FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerI, BitrateIndex: 14, SamplingFrequencyIndex: 2, Mode: ModeDualChannel}, nil},
+		{MPEGVersion1, MPEGLayerIII, 0, 48000, ModeStereo, FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: BitrateIndexFree, SamplingFrequencyIndex: 1, Mode: ModeStereo}, nil},
+		{MPEGVersion1, MPEGLayerIII, 129, 44100, ModeStereo, FrameHeader{}, ErrInvalidBitrate},
+		{MPEGVersion1, MPEGLayerIII, 448, 44100, ModeStereo, FrameHeader{}, ErrInvalidBitrate},
+		{MPEGVersion1, MPEGLayerIII, 128, 22050, ModeStereo, FrameHeader{}, ErrInvalidSampleRate},
+		{MPEGVersionReserved, MPEGLayerIII, 128, 44100, ModeStereo, FrameHeader{}, ErrReservedVersion},
+		{MPEGVersion1, MPEGLayerReserved, 128, 44100, ModeStereo, FrameHeader{}, ErrReservedLayer},
+		{MPEGVersion1, MPEGLayerIII, 128, 44100, Mode(4), FrameHeader{}, ErrInvalidMode},
+	} {
+		h, err := NewFrameHeader(tc.Version, tc.Layer, tc.Bitrate, tc.SampleRate, tc.Mode)
+		if err != tc.Err {
+			t.Errorf("%s %s %d %d %s: expected error %v, got %v", tc.Version, tc.Layer, tc.Bitrate, tc.SampleRate, tc.Mode, tc.Err, err)
+		} else if h != tc.Header {
+			t.Errorf("%s %s %d %d %s: expected %s, got %s", tc.Version, tc.Layer, tc.Bitrate, tc.SampleRate, tc.Mode, tc.Header, h)
+		}
+	}
+}
+
+func TestIndexFor(t *testing.T) {
+	for version := range MPEGVersion(4) {
+		for layer := range MPEGLayer(4) {
+			for i := range BitrateIndex(16) {
+				kbps, ok := i.Bitrate(version, layer)
+				if !ok {
+					continue
+				}
+				if j, ok := BitrateIndexFor(kbps, version, layer); !ok || j != i {
+					t.Errorf("%s %s: bitrate %d: expected index %d, got %d (ok=%t)", version, layer, kbps, i, j, ok)
+				}
+			}
+			if _, ok := BitrateIndexFor(1, version, layer); ok {
+				t.Errorf("%s %s: expected invalid bitrate", version, layer)
+			}
+		}
+		for i := range SamplingFrequencyIndex(4) {
+			hz, ok := i.SamplingFrequency(version)
+			if !ok {
+				continue
+			}
+			if j, ok := SamplingFrequencyIndexFor(hz, version); !ok || j != i {
+				t.Errorf("%s: sampling frequency %d: expected index %d, got %d (ok=%t)", version, hz, i, j, ok)
+			}
+		}
+		if _, ok := SamplingFrequencyIndexFor(44000, version); ok {
+			t.Errorf("%s: expected invalid sampling frequency", version)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	f := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	for _, tc := range []struct {
+		Modify            func(*FrameHeader)
+		Equal, Compatible bool
+	}{
+		{func(h *FrameHeader) {}, true, true},
+		{func(h *FrameHeader) { h.BitrateIndex = 10 }, false, true},
+		{func(h *FrameHeader) { h.Padding = true }, false, true},
+		{func(h *FrameHeader) { h.Copyright, h.Original, h.Private = true, true, true }, false, true},
+		{func(h *FrameHeader) { h.Mode = ModeStereo }, false, true},
+		{func(h *FrameHeader) { h.Mode = ModeSingleChannel }, false, false},
+		{func(h *FrameHeader) { h.SamplingFrequencyIndex = 1 }, false, false},
+		{func(h *FrameHeader) { h.Layer = MPEGLayerII }, false, false},
+		{func(h *FrameHeader) { h.ID = MPEGVersion2 }, false, false},
+	} {
+		g := f
+		tc.Modify(&g)
+		if act := f.Equal(g); act != tc.Equal {
+			t.Errorf("%s: expected equal %t, got %t", g, tc.Equal, act)
+		}
+		if act := f.Compatible(g); act != tc.Compatible {
+			t.Errorf("%s: expected compatible %t, got %t", g, tc.Compatible, act)
+		}
+	}
+}
+
+func TestEnumText(t *testing.T) {
+	testEnumText[MPEGVersion](t, "mpeg-1", "mpeg-2", "mpeg-2.5")
+	testEnumText[MPEGLayer](t, "layer-1", "layer-2", "layer-3")
+	testEnumText[Mode](t, "stereo", "joint-stereo", "dual-channel", "single-channel")
+	testEnumText[Emphasis](t, "none", "50/15-microsecond", "ccitt-j.17")
+}
+
+func testEnumText[T ~uint8, P interface {
+	*T
+	encoding.TextMarshaler
+	encoding.TextUnmarshaler
+}](t *testing.T, names ...string) {
+	for x := T(0); x <= 0b11; x++ {
+		b, err := P(&x).MarshalText()
+		if err != nil {
+			t.Errorf("%T(%d): marshal: %v", x, x, err)
+			continue
+		}
+		var y T
+		if err := P(&y).UnmarshalText(b); err != nil {
+			t.Errorf("%T(%d): unmarshal %q: %v", x, x, b, err)
+		} else if y != x {
+			t.Errorf("%T(%d): unmarshal %q: got %d", x, x, b, y)
+		}
+	}
+	for _, name := range names {
+		var y T
+		if err := P(&y).UnmarshalText([]byte(name)); err != nil {
+			t.Errorf("%T: unmarshal %q: %v", y, name, err)
+		} else if b, _ := P(&y).MarshalText(); string(b) != name {
+			t.Errorf("%T: unmarshal %q: got %q", y, name, b)
+		}
+	}
+	var y T
+	if err := P(&y).UnmarshalText([]byte("unknown")); err == nil {
+		t.Errorf("%T: expected error for unknown value", y)
+	}
+}
+
+func TestSync(t *testing.T) {
+	naive := func(b []byte) int {
+		for i := range b {
+			if IsSyncword(b[i:]) {
+				return i
+			}
+		}
+		return -1
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 10000 {
+		b := make([]byte, rng.IntN(64))
+		for i := range b {
+			switch rng.IntN(4) {
+			case 0:
+				b[i] = 0xFF
+			case 1:
+				b[i] = 0xE0 | byte(rng.IntN(32))
+			default:
+				b[i] = byte(rng.IntN(256))
+			}
+		}
+		if act, exp := Sync(b), naive(b); act != exp {
+			t.Fatalf("sync %x: expected %d, got %d", b, exp, act)
+		}
+	}
+}
+
+func BenchmarkSync(b *testing.B) {
+	buf := make([]byte, 4<<20)
+	for i := range buf {
+		buf[i] = byte(i % 0xFF) // no syncwords, and few 0xFF bytes
+	}
+	buf[len(buf)-2], buf[len(buf)-1] = 0xFF, 0xFB
+	b.SetBytes(int64(len(buf)))
+	for range b.N {
+		if Sync(buf) != len(buf)-2 {
+			b.Fatal("incorrect result")
+		}
+	}
+}
+
+func TestFrameHeaderAllocs(t *testing.T) {
+	var (
+		h   FrameHeader
+		buf = make([]byte, FrameHeaderSize)
+		dst = make([]byte, 0, FrameHeaderSize)
+		src = bytes.NewReader(nil)
+		raw = []byte{0xFF, 0xFB, 0x90, 0x64}
+	)
+	if n := testing.AllocsPerRun(100, func() {
+		src.Reset(raw)
+		if _, err := h.ReadFromBuf(src, buf); err != nil {
+			t.Fatalf("read header: %v", err)
+		}
+		if _, err := h.AppendBinary(dst[:0]); err != nil {
+			t.Fatalf("append header: %v", err)
+		}
+	}); n != 0 {
+		t.Errorf("expected no allocations, got %f", n)
+	}
+	out := bytes.NewBuffer(make([]byte, 0, FrameHeaderSize))
+	if n := testing.AllocsPerRun(100, func() {
+		src.Reset(raw)
+		if _, err := h.ReadFrom(src); err != nil {
+			t.Fatalf("read header: %v", err)
+		}
+		out.Reset()
+		if _, err := h.WriteTo(out); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+	}); n != 0 {
+		t.Errorf("expected no allocations, got %f", n)
+	}
+	if _, err := h.ReadFromBuf(src, buf[:FrameHeaderSize-1]); err == nil {
+		t.Errorf("expected error for short buffer")
+	}
+}
+
+func TestFrameHeaderUint32(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_mode.mp3")
+	if err != nil {
+		panic(err)
+	}
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		v := binary.BigEndian.Uint32(r.Raw())
+		if h, ok := DecodeHeaderUint32(v); !ok || h != *r.Header() {
+			t.Errorf("offset %d: expected header %s, got %s (ok=%t)", r.Offset(), r.Header(), h, ok)
+		}
+		if act := r.Header().Uint32(); act != v {
+			t.Errorf("offset %d: expected %08X, got %08X", r.Offset(), v, act)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	for _, v := range []uint32{0, 0xFFC0_0000, 0xFEFB_9064, 0x00FF_FB90} {
+		if _, ok := DecodeHeaderUint32(v); ok {
+			t.Errorf("%08X: expected no syncword", v)
+		}
+	}
+	if n := testing.AllocsPerRun(100, func() {
+		h, _ := DecodeHeaderUint32(0xFFFB_9064)
+		_ = h.Uint32()
+	}); n != 0 {
+		t.Errorf("expected no allocations, got %f", n)
+	}
+}
+
+func BenchmarkFrameHeaderReadFromBuf(b *testing.B) {
+	var (
+		h   FrameHeader
+		buf = make([]byte, FrameHeaderSize)
+		src = bytes.NewReader(nil)
+		raw = bytes.Repeat([]byte{0xFF, 0xFB, 0x90, 0x64}, 1024)
+	)
+	b.ReportAllocs()
+	b.SetBytes(FrameHeaderSize)
+	for i := range b.N {
+		if i%1024 == 0 {
+			src.Reset(raw)
+		}
+		if _, err := h.ReadFromBuf(src, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package mp3
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// ReaderPool contains readers returned by [PutReader] for reuse by
+// [GetReader].
+var ReaderPool sync.Pool
+
+// GetReader is like [NewReader], but reuses a reader (and its buffer) from
+// [ReaderPool] if one is available. The returned reader is in the same state as
+// a new one.
+func GetReader(r io.Reader, buffer int) *Reader {
+	rd, ok := ReaderPool.Get().(*Reader)
+	if !ok {
+		return NewReader(r, buffer)
+	}
+	if buffer <= FrameHeaderSize {
+		panic("mp3: invalid buffer size " + strconv.Itoa(buffer))
+	}
+	br := rd.reader
+	if buffer != br.Size() {
+		br = bufio.NewReaderSize(r, buffer)
+	} else {
+		br.Reset(r)
+	}
+	// like NewReaderSize, but reusing the buffers
+	*rd = Reader{
+		source:    r,
+		reader:    br,
+		end:       -1,
+		reservoir: rd.reservoir[:0],
+		logical:   rd.logical[:0],
+	}
+	return rd
+}
+
+// PutReader resets r and adds it to [ReaderPool]. The reader, and any slices
+// returned by it (e.g., [Reader.Raw] and [Reader.Data]), must not be used
+// afterwards.
+func PutReader(r *Reader) {
+	r.Reset(nil, 0)
+	ReaderPool.Put(r)
+}
@@ -0,0 +1,69 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestGetReader(t *testing.T) {
+	testStreams(t, testGetReader)
+
+	// the rate mode from the xing header is only read for the first frame, and
+	// overrides the one detected from the bitrates
+	audio, err := fs.ReadFile(testdata, "testdata/layer3/si.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(audio[:FrameHeaderSize]); err != nil {
+		panic(err)
+	}
+	buf := slices.Concat(testInfoFrame(h, XingHeader{Tag: "Xing"}, nil), audio)
+	testGetReader(t, buf)
+	r := GetReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+	}
+	if act := r.RateMode(); act != RateVBR {
+		t.Errorf("expected rate mode %s, got %s", RateVBR, act)
+	}
+	PutReader(r)
+
+	// the buffer is only reused if it has the same size
+	r = GetReader(bytes.NewReader(buf), 8192)
+	if act := r.Size(); act != 8192 {
+		t.Errorf("expected buffer size %d, got %d", 8192, act)
+	}
+	PutReader(r)
+}
+
+func testGetReader(t *testing.T, buf []byte) {
+	exp := NewReader(bytes.NewReader(buf), 16384)
+	for exp.Next() {
+	}
+
+	// twice to reuse the reader with state from the first one
+	for range 2 {
+		r := GetReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+		}
+		if (r.Err() == nil) != (exp.Err() == nil) {
+			t.Errorf("expected error %v, got %v", exp.Err(), r.Err())
+		}
+		if r.Offset() != exp.Offset() || r.Time() != exp.Time() || r.SamplePosition() != exp.SamplePosition() {
+			t.Errorf("expected offset %d, time %s, and sample position %d, got %d, %s, and %d", exp.Offset(), exp.Time(), exp.SamplePosition(), r.Offset(), r.Time(), r.SamplePosition())
+		}
+		if act, exp := r.BitrateHistogram(), exp.BitrateHistogram(); !maps.Equal(act, exp) {
+			t.Errorf("expected bitrates %v, got %v", exp, act)
+		}
+		if act, exp := r.RateMode(), exp.RateMode(); act != exp {
+			t.Errorf("expected rate mode %s, got %s", exp, act)
+		}
+		PutReader(r)
+		if r.source != nil {
+			t.Errorf("expected source to be released")
+		}
+	}
+}
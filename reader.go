@@ -2,53 +2,252 @@ package mp3
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"io"
+	"iter"
+	"maps"
 	"strconv"
 	"time"
 )
 
 // Reader reads frames of an audio stream.
 type Reader struct {
+	source io.Reader
 	reader *bufio.Reader
+	ctx    context.Context // of the current call to NextContext, if any
 	offset int64
 	err    error
+	bad    bool // whether err was caused by invalid data (see corruptError)
+
+	validate bool
+	strict   bool
+	skip     bool
+	skipped  int64
+	onResync func(from, to int64)
+
+	id3v2 int64
+	id3v1 []byte
+	ape   int64
+
+	tail bool  // whether trailing tags have been checked
+	end  int64 // offset of the end of the audio data, or -1 if unknown
+	base int64 // offset of the start of the source, if tail
+
+	resync  bool        // whether to synchronize before reading the next frame
+	last    FrameHeader // of the last frame successfully read, if hasLast
+	hasLast bool
+	prev    FrameHeader // of the frame successfully read before last, if hasPrev
+	hasPrev bool
+
+	free int // slots in a free format frame, or 0 if not measured yet
+
+	limit   int // number of frames remaining, if limited
+	limited bool
+
+	reservoir []byte // main data from previous layer 3 frames
+	logical   []byte
 
 	header FrameHeader
 	data   []byte
 
-	time time.Duration
+	time    time.Duration
+	samples int64
+
+	bits     int64         // in all frames read
+	bitsTime time.Duration // of all frames read
+	bitrates map[int]int   // frame count by bitrate
+	rate     RateMode      // from the VBR header of the first frame, if any
 }
 
-// NewReader creates a new reader reading from r. The specified buffer size must
-// fit an entire frame, and must fit the distance between the beginning of r and
-// the first syncword.
+// NewReader is like [NewReaderSize], but panics if the buffer size is invalid.
 func NewReader(r io.Reader, buffer int) *Reader {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		panic("mp3: " + err.Error())
+	}
+	return rd
+}
+
+// NewReaderSize creates a new reader reading from r. The specified buffer size
+// must fit an entire frame. Data before the first syncword does not need to fit
+// in the buffer.
+func NewReaderSize(r io.Reader, buffer int) (*Reader, error) {
 	if buffer <= FrameHeaderSize {
-		panic("mp3: invalid buffer size " + strconv.Itoa(buffer))
+		return nil, errors.New("invalid buffer size " + strconv.Itoa(buffer))
 	}
 	return &Reader{
+		source: r,
 		reader: bufio.NewReaderSize(r, buffer),
+		end:    -1,
+	}, nil
+}
+
+// maxFrameSize is the size of the largest possible frame which is not free
+// format (MPEG-2.5 Layer II at 160 kbit/s and 8 kHz, with padding).
+const maxFrameSize = 2881
+
+// NewReaderBuffered creates a new reader reading directly from br without
+// additional buffering. It panics if the buffer size of br does not fit the
+// largest possible frame which is not free format. Anything read from br
+// between calls to [Reader.Next] is not seen by the Reader (and is not counted
+// in [Reader.Offset]), and may overwrite the data returned by [Reader.Raw].
+func NewReaderBuffered(br *bufio.Reader) *Reader {
+	if br.Size() < maxFrameSize {
+		panic("mp3: buffer size " + strconv.Itoa(br.Size()) + " is too small for a frame")
+	}
+	return &Reader{
+		source: br,
+		reader: br,
+		end:    -1,
 	}
 }
 
 // Reset clears the buffered data and error, replacing the underlying reader and
 // the current offset. If offset is 0, the stream is resynchronized on the next
-// call to Next. The time is not reset.
+// call to Next. The offset is the position of x in the stream, so x may either
+// be positioned at offset or start at offset (e.g., a slice of the stream). The
+// time, sample position, bitrate statistics, and number of skipped bytes are
+// not reset.
 func (r *Reader) Reset(x io.Reader, offset int64) {
 	if offset < 0 {
 		offset = 0
 	}
+	r.source = x
 	r.reader.Reset(x)
 	r.offset = offset
 	r.err = nil
 	r.header = FrameHeader{}
 	r.data = nil
+	r.free = 0
+	r.id3v2 = 0
+	r.id3v1 = nil
+	r.ape = 0
+	r.tail = false
+	r.end = -1
+	r.resync = false
+	r.hasLast = false
+	r.hasPrev = false
+	r.limited = false
+	r.reservoir = r.reservoir[:0]
 }
 
-// Validate causes the Reader to fail if the checksum for a frame is incorrect.
-// TODO: func (r *Reader) ValidateChecksum()
+// ResetSize is like [Reader.Reset], but also changes the buffer size, which
+// must fit an entire frame. The buffer is only reallocated if the size differs.
+func (r *Reader) ResetSize(x io.Reader, offset int64, buffer int) {
+	if buffer <= FrameHeaderSize {
+		panic("mp3: invalid buffer size " + strconv.Itoa(buffer))
+	}
+	if buffer != r.reader.Size() {
+		r.reader = bufio.NewReaderSize(x, buffer)
+	}
+	r.Reset(x, offset)
+}
+
+// Seek sets the offset for the next call to Next, which will read the first
+// frame starting at or after it. The underlying reader must be an [io.Seeker].
+// The offset is interpreted according to whence as with [io.Seeker] (with
+// [io.SeekCurrent] being relative to the current offset of the Reader), and the
+// new offset, which is relative to the start of the underlying reader, is
+// returned. The time and sample position are not changed.
+//
+// Since the new offset is likely to be in the middle of a frame, the reader
+// synchronizes as if [Reader.StrictSync] was enabled, which requires the buffer
+// to fit two frames. If a frame was previously read, the next frame must also
+// have the same version, layer, and sampling frequency.
+//
+// Since the frames before the new offset are not read, the Layer III bit
+// reservoir is empty after seeking, so the main data for the first few frames
+// may be incomplete (see [Reader.LogicalMainData]).
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	sk, ok := r.source.(io.Seeker)
+	if !ok {
+		return r.offset, errors.New("underlying reader is not an io.Seeker")
+	}
+	if whence == io.SeekCurrent {
+		// relative to the current offset rather than the position of the
+		// source, which includes buffered data
+		cur, err := sk.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return r.offset, err
+		}
+		offset, whence = cur-int64(r.reader.Buffered())+offset, io.SeekStart
+	}
+	abs, err := sk.Seek(offset, whence)
+	if err != nil {
+		return r.offset, err
+	}
+	r.reader.Reset(r.source)
+	r.offset = abs
+	if r.tail && r.end != -1 {
+		r.end -= r.base // the new offset is relative to the start of the source
+	}
+	r.base = 0
+	r.err = nil
+	r.header = FrameHeader{}
+	r.data = nil
+	r.resync = true
+	r.reservoir = r.reservoir[:0]
+	if abs == 0 {
+		r.id3v2 = 0
+	}
+	return abs, nil
+}
+
+// ValidateChecksum causes the Reader to fail with [ErrChecksumMismatch] if the
+// checksum for a protected frame is incorrect. Frames for which the checksum
+// cannot be computed are not validated.
+func (r *Reader) ValidateChecksum(validate bool) {
+	r.validate = validate
+}
+
+// StrictSync causes the Reader to only accept a syncword when synchronizing if
+// it is the start of a valid frame which is immediately followed by another
+// valid frame with the same version, layer, and sampling frequency (or the end
+// of the stream). This reduces false positives when synchronizing at the cost
+// of requiring the buffer to fit two frames.
+func (r *Reader) StrictSync(strict bool) {
+	r.strict = strict
+}
+
+// SkipErrors causes the Reader to resynchronize at the next syncword instead of
+// failing when a frame is invalid. Frames with an incorrect checksum (see
+// [Reader.ValidateChecksum]) are dropped.
+func (r *Reader) SkipErrors(skip bool) {
+	r.skip = skip
+}
+
+// Skipped returns the total number of bytes skipped due to invalid frames (see
+// [Reader.SkipErrors]).
+func (r *Reader) Skipped() int64 {
+	return r.skipped
+}
+
+// LimitFrames causes Next to return false without an error after n more frames
+// have been read, even if there is more data (e.g., trailing junk after the
+// last frame). This is usually used with [XingHeader.Frames] after reading the
+// first frame containing the Xing header. If n is negative, the limit is
+// removed. It is also removed by [Reader.Reset].
+func (r *Reader) LimitFrames(n int) {
+	r.limit, r.limited = n, n >= 0
+}
+
+// OnResync sets a function to be called with the range of bytes [from, to)
+// whenever bytes are skipped due to an invalid frame (see [Reader.SkipErrors]).
+// Contiguous skipped bytes are reported as a single range once the next frame
+// is read. If fn is nil, the existing function is removed.
+func (r *Reader) OnResync(fn func(from, to int64)) {
+	r.onResync = fn
+}
+
+// skippedRange calls the OnResync function, if any, if from < to.
+func (r *Reader) skippedRange(from, to int64) {
+	if r.onResync != nil && from < to {
+		r.onResync(from, to)
+	}
+}
 
 // Err gets the current error. It is nil if no error occurred or the error is
 // [io.EOF].
@@ -68,127 +267,991 @@ func (r *Reader) Err() error {
 // the start of the frame, and the total length of all raw frames plus the
 // offset of the first syncword equals the length of the stream.
 func (r *Reader) Next() bool {
+	return r.NextContext(context.Background())
+}
+
+// NextContext is like [Reader.Next], but stops if ctx is canceled. The context
+// is checked before reading each frame and between buffer fills while
+// synchronizing or skipping data, and its error is returned by [Reader.Err].
+func (r *Reader) NextContext(ctx context.Context) bool {
 	if r.err != nil {
 		return false
 	}
+	r.ctx = ctx
+	r.bad = false
 	r.err = r.next()
+	r.ctx = nil
 	return r.err == nil
 }
 
+// canceled returns the error of the context of the current call to
+// NextContext, if any.
+func (r *Reader) canceled() error {
+	if r.ctx == nil {
+		return nil
+	}
+	return r.ctx.Err()
+}
+
+// PeekHeader decodes the header at the current offset, which is the start of
+// the next frame, without advancing the reader. The header is not validated.
+// If the reader has not synchronized yet (i.e., before the first call to Next,
+// or after [Reader.Seek]), there is no syncword at the current offset, or an
+// error has occurred, false is returned.
+func (r *Reader) PeekHeader() (FrameHeader, bool) {
+	var f FrameHeader
+	if r.err != nil || r.resync || r.offset == 0 {
+		return f, false
+	}
+	if r.end != -1 && r.offset+FrameHeaderSize > r.end {
+		return f, false
+	}
+	buf, err := r.reader.Peek(FrameHeaderSize)
+	if err != nil || !IsSyncword(buf) {
+		return f, false
+	}
+	f.decode(buf)
+	return f, true
+}
+
+// DistanceToNextSync returns the number of bytes from the current offset (i.e.,
+// the start of the next frame) to the following syncword (see [Sync]), which is
+// the size of the next frame unless it is corrupted or the syncword is a false
+// positive within the frame data. Only data which fits in the buffer is
+// searched. If the reader has not synchronized yet (see [Reader.PeekHeader]),
+// an error has occurred, or there is no syncword, false is returned.
+func (r *Reader) DistanceToNextSync() (int, bool) {
+	if r.err != nil || r.resync || r.offset == 0 {
+		return 0, false
+	}
+	buf, err := r.reader.Peek(r.reader.Size())
+	if err != nil && err != io.EOF {
+		return 0, false
+	}
+	if r.end != -1 && int64(len(buf)) > r.end-r.offset {
+		buf = buf[:max(r.end-r.offset, 0)]
+	}
+	if len(buf) < FrameHeaderSize {
+		return 0, false
+	}
+	// the header itself may contain a false syncword
+	i := Sync(buf[FrameHeaderSize:])
+	if i == -1 {
+		return 0, false
+	}
+	return i + FrameHeaderSize, true
+}
+
+// NextN reads up to n frames, returning the number of frames read. It is
+// equivalent to calling Next n times, stopping early if it returns false.
+// Afterwards, the current frame is the last one read.
+func (r *Reader) NextN(n int) int {
+	var i int
+	for i < n && r.Next() {
+		i++
+	}
+	return i
+}
+
+// WriteTo implements [io.WriterTo] by reading the remaining frames (not
+// including the current one) and writing their raw data to w until the end of
+// the stream or an error occurs. Afterwards, the current frame is the last one
+// read.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for r.Next() {
+		n, err := w.Write(r.data)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, r.Err()
+}
+
+// ExtractAudio reads all frames from r using a [Reader] with the specified
+// buffer size, returning their raw data (i.e., the stream without any tags or
+// data between frames).
+func ExtractAudio(r io.Reader, buffer int) ([]byte, error) {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	_, err = rd.WriteTo(&b)
+	return b.Bytes(), err
+}
+
+// ErrScan is returned by [Scan] and [ScanHeaders] when the callback returns an
+// error.
+type ErrScan struct {
+	Offset int64 // of the start of the frame
+	Err    error // returned by the callback
+}
+
+func (err *ErrScan) Error() string {
+	return "scan frame at offset " + strconv.FormatInt(err.Offset, 10) + ": " + err.Err.Error()
+}
+
+func (err *ErrScan) Unwrap() error {
+	return err.Err
+}
+
+// Scan reads all frames from r using a [Reader] with the specified buffer size,
+// calling fn with the header, raw data, and offset of each one. The raw data is
+// only valid until fn returns. If fn returns an error, scanning stops, and it
+// is returned wrapped in an [ErrScan].
+func Scan(r io.Reader, buffer int, fn func(f FrameHeader, raw []byte, offset int64) error) error {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return err
+	}
+	for rd.Next() {
+		offset := rd.Offset() - int64(len(rd.data))
+		if err := fn(rd.header, rd.data, offset); err != nil {
+			return &ErrScan{Offset: offset, Err: err}
+		}
+	}
+	return rd.Err()
+}
+
+// ErrNotContiguous is returned by [VerifyContiguous] when a frame is not
+// immediately followed by another one or the end of the audio data.
+type ErrNotContiguous struct {
+	Offset int64 // of the end of the last frame
+	Err    error // from the Reader
+}
+
+func (err *ErrNotContiguous) Error() string {
+	return "frames not contiguous at offset " + strconv.FormatInt(err.Offset, 10) + ": " + err.Err.Error()
+}
+
+func (err *ErrNotContiguous) Unwrap() error {
+	return err.Err
+}
+
+// VerifyContiguous reads all frames from r using a [Reader] with the specified
+// buffer size, checking that each frame is immediately followed by another one
+// or the end of the audio data (i.e., that there is no truncation or data
+// between frames), and returning the number of frames read. Data before the
+// first frame is skipped. If the frames are not contiguous, an
+// [ErrNotContiguous] is returned. Other errors (e.g., from r) are returned
+// as-is.
+func VerifyContiguous(r io.Reader, buffer int) (frames int, err error) {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return 0, err
+	}
+	for rd.Next() {
+		frames++
+	}
+	if err := rd.Err(); err != nil {
+		var terr *ErrTruncatedFrame
+		if frames != 0 && (rd.bad || errors.As(err, &terr)) {
+			return frames, &ErrNotContiguous{Offset: rd.Offset(), Err: err}
+		}
+		return frames, err
+	}
+	return frames, nil
+}
+
+// ScanHeaders is like [Scan], but only reads the header of each frame from r,
+// seeking past the rest, which is much faster when only the headers are
+// needed. The offsets are relative to the start of r. Leading ID3v2 tags and
+// trailing ID3v1 and APEv2 tags are skipped, but unlike [Scan], the frames must
+// be contiguous after the first syncword, and free format frames must all have
+// the same size as the first one (excluding the padding slot).
+func ScanHeaders(r io.ReadSeeker, fn func(f FrameHeader, offset int64) error) error {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	ra := seekReaderAt{r}
+	end, _, _, err := readTailTags(ra, size)
+	if err != nil {
+		return err
+	}
+
+	var off int64
+	buf := make([]byte, indexBufferSize)
+
+	// skip ID3v2 tags
+	for off+id3v2HeaderSize <= end {
+		if _, err := ra.ReadAt(buf[:id3v2HeaderSize], off); err != nil {
+			return err
+		}
+		n, ok := id3v2Size(buf[:id3v2HeaderSize])
+		if !ok {
+			break
+		}
+		off += n
+	}
+
+	// find the first syncword
+	for {
+		b := buf[:min(int64(len(buf)), max(end-off, 0))]
+		if _, err := ra.ReadAt(b, off); err != nil {
+			return err
+		}
+		if i := Sync(b); i != -1 {
+			off += int64(i)
+			break
+		}
+		if off+int64(len(b)) >= end {
+			return ErrUnsynchronized
+		}
+		off += int64(len(b) - 1) // the syncword may span the boundary
+	}
+
+	var free int // slots in a free format frame, or 0 if not measured yet
+	for end-off >= FrameHeaderSize {
+		b := buf[:FrameHeaderSize]
+		if _, err := ra.ReadAt(b, off); err != nil {
+			return err
+		}
+		if !IsSyncword(b) {
+			return ErrUnsynchronized
+		}
+		var f FrameHeader
+		f.decode(b)
+		if err := checkHeader(f); err != nil {
+			return err
+		}
+
+		if f.BitrateIndex == BitrateIndexFree && free == 0 {
+			// read enough to measure the free format frame size
+			b = buf[:min(int64(len(buf)), end-off)]
+			if _, err := ra.ReadAt(b, off); err != nil {
+				return err
+			}
+		}
+		size, ok := frameSizeAt(f, b, &free)
+		if !ok {
+			return errors.New("could not determine frame size")
+		}
+		if off+int64(size) > end {
+			return &ErrTruncatedFrame{Offset: off, Size: size, Available: int(end - off)}
+		}
+		if err := fn(f, off); err != nil {
+			return &ErrScan{Offset: off, Err: err}
+		}
+		off += int64(size)
+	}
+	return nil
+}
+
+// seekReaderAt implements [io.ReaderAt] for an [io.ReadSeeker].
+type seekReaderAt struct {
+	r io.ReadSeeker
+}
+
+func (s seekReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.r, b)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
 func (r *Reader) next() error {
+	r.fillReservoir()
+
+	if r.limited && r.limit <= 0 {
+		return io.EOF
+	}
+
+	if !r.tail {
+		if err := r.readTail(); err != nil {
+			return err
+		}
+		r.tail = true
+	}
+	if r.end != -1 && r.offset >= r.end {
+		return io.EOF
+	}
+
 	if r.offset == 0 {
-		buf, err := r.reader.Peek(r.reader.Size())
-		if err != nil && err != io.EOF {
+		// skip ID3v2 tags, which may be larger than the buffer
+		for {
+			if err := r.canceled(); err != nil {
+				return err
+			}
+			buf, _ := r.reader.Peek(id3v2HeaderSize)
+			size, ok := id3v2Size(buf)
+			if !ok {
+				break
+			}
+			n, err := r.reader.Discard(int(size))
+			r.offset += int64(n)
+			r.id3v2 += int64(n)
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := r.sync(r.strict, nil); err != nil {
 			return err
 		}
-		i := Sync(buf)
-		if i == -1 {
-			return ErrUnsynchronized
+		r.resync = false
+	}
+	if r.resync {
+		if err := r.syncSeek(); err != nil {
+			return err
+		}
+	}
+
+	// contiguous skipped ranges are reported together
+	var skipFrom, skipTo int64
+	defer func() {
+		r.skippedRange(skipFrom, skipTo)
+	}()
+	skip := func(from, to int64) {
+		if from != skipTo {
+			r.skippedRange(skipFrom, skipTo)
+			skipFrom = from
+		}
+		skipTo = to
+	}
+
+	for {
+		if err := r.canceled(); err != nil {
+			return err
+		}
+		err := r.readFrame()
+
+		var cerr corruptError
+		if !errors.As(err, &cerr) {
+			return err
+		}
+		if !r.skip {
+			r.bad = true
+			return cerr.error
+		}
+
+		// bit reservoir continuity is broken
+		r.reservoir = r.reservoir[:0]
+
+		// the frame was read successfully, so we can just drop it
+		if _, ok := cerr.error.(*ErrChecksumMismatch); ok {
+			r.skipped += int64(len(r.data))
+			skip(r.offset-int64(len(r.data)), r.offset)
+			r.data = nil
+			continue
 		}
-		n, err := r.reader.Discard(i)
+		r.data = nil
+
+		from := r.offset
+		n, err := r.reader.Discard(1)
 		r.offset += int64(n)
+		r.skipped += int64(n)
 		if err != nil {
+			skip(from, r.offset)
 			return err
 		}
-	}
 
-	buf, err := r.reader.Peek(FrameHeaderSize)
-	if err != nil {
-		return err
+		m, err := r.sync(r.strict, nil)
+		r.skipped += m
+		skip(from, r.offset)
+		if err == ErrUnsynchronized {
+			// there's no syncword before the end, and the rest was skipped
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
 	}
-	r.header.decode(buf)
+}
 
-	switch r.header.ID {
+// ErrTruncatedFrame is returned when the stream ends before the end of the last
+// frame. It matches [io.ErrUnexpectedEOF] with [errors.Is].
+type ErrTruncatedFrame struct {
+	Offset    int64 // of the start of the frame
+	Size      int   // of the frame
+	Available int   // bytes of the frame before the end of the stream
+}
+
+func (err *ErrTruncatedFrame) Error() string {
+	return "truncated frame at offset " + strconv.FormatInt(err.Offset, 10) + " (" + strconv.Itoa(err.Available) + " of " + strconv.Itoa(err.Size) + " bytes)"
+}
+
+func (err *ErrTruncatedFrame) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// corruptError wraps an error caused by invalid data in the stream which can be
+// recovered from by resynchronizing.
+type corruptError struct {
+	error
+}
+
+func (err corruptError) Unwrap() error {
+	return err.error
+}
+
+// checkHeader checks the fields of f required to read a frame. Unlike
+// [FrameHeader.Valid], the mode and emphasis are not checked.
+func checkHeader(f FrameHeader) error {
+	switch f.ID {
 	case MPEGVersion1, MPEGVersion2, MPEGVersion2_5:
+	case MPEGVersionReserved:
+		return ErrReservedVersion
 	default:
-		return errors.New("invalid mpeg version")
+		return ErrInvalidVersion
 	}
-	switch r.header.Layer {
+	switch f.Layer {
 	case MPEGLayerI, MPEGLayerII, MPEGLayerIII:
+	case MPEGLayerReserved:
+		return ErrReservedLayer
 	default:
-		return errors.New("invalid mpeg layer")
+		return ErrInvalidLayer
 	}
-	if _, ok := r.header.Bitrate(); !ok {
-		return errors.New("invalid bitrate index")
+	if _, ok := f.Bitrate(); !ok {
+		return ErrInvalidBitrate
 	}
-	samplingFrequency, ok := r.header.SamplingFrequency()
-	if !ok {
-		return errors.New("invalid sampling frequency index")
+	if _, ok := f.SamplingFrequency(); !ok {
+		return ErrInvalidSampleRate
 	}
+	return nil
+}
 
-	var slots int
+// readFrame reads a frame at the current offset.
+func (r *Reader) readFrame() error {
+	buf, err := r.reader.Peek(FrameHeaderSize)
+	if err != nil {
+		return err
+	}
+	if !IsSyncword(buf) {
+		return corruptError{ErrUnsynchronized}
+	}
+	r.header.decode(buf)
+	if err := checkHeader(r.header); err != nil {
+		return corruptError{err}
+	}
+
+	var bytes int
 	if r.header.BitrateIndex == BitrateIndexFree {
-		return errors.New("free bitrate index not implemented yet") // TODO
+		slotSize, ok := r.header.SlotSize()
+		if !ok {
+			return corruptError{errors.New("invalid slot size")}
+		}
+		if r.free == 0 {
+			n, err := r.measureFree()
+			if err != nil {
+				return corruptError{err}
+			}
+			r.free = n
+		}
+		bytes = r.free * slotSize
+		if r.header.Padding {
+			bytes += slotSize
+		}
 	} else {
 		var ok bool
-		slots, _, ok = r.header.Slots()
+		bytes, ok = r.header.FrameSize()
 		if !ok {
-			panic("wtf") // this should never fail if the checks above passed
+			return corruptError{errors.New("invalid frame size")}
 		}
 	}
-
-	sampleCount, ok := r.header.SampleCount()
-	if !ok {
-		panic("wtf") // this should never fail
+	if bytes < FrameHeaderSize {
+		return corruptError{errors.New("invalid frame size")}
 	}
-	r.time += time.Second * time.Duration(sampleCount) / time.Duration(samplingFrequency)
 
-	slotSize, ok := r.header.SlotSize()
+	duration, ok := r.header.Duration()
 	if !ok {
-		panic("wtf") // this should never fail if the checks above passed
+		return corruptError{errors.New("invalid frame duration")}
 	}
-
-	bytes := slots * slotSize
-	if r.header.Padding {
-		bytes += slotSize
+	sampleCount, ok := r.header.SampleCount()
+	if !ok {
+		return corruptError{errors.New("invalid frame sample count")}
 	}
-	if bytes < FrameHeaderSize {
-		panic("wtf") // this should never fail if the checks above passed
+	if r.end != -1 && r.offset+int64(bytes) > r.end {
+		return &ErrTruncatedFrame{Offset: r.offset, Size: bytes, Available: int(r.end - r.offset)}
 	}
 
 	// we use Peek instead of ReadFull to ensure no more than the configured
 	// buffer size is read
 	buf, err = r.reader.Peek(bytes)
 	if err == io.EOF {
-		err = io.ErrUnexpectedEOF
+		return &ErrTruncatedFrame{Offset: r.offset, Size: bytes, Available: len(buf)}
 	}
 	if err != nil {
 		return err
 	}
 	r.data = buf
 
+	// the free format frame size is only measured once, so ensure the frame
+	// actually ends where we expect it to (i.e., the padding bit is correct)
+	if r.header.BitrateIndex == BitrateIndexFree {
+		if buf, _ := r.reader.Peek(bytes + 2); len(buf) == bytes+2 && !IsSyncword(buf[bytes:]) {
+			return corruptError{errors.New("free format frame size mismatch")}
+		}
+	}
+
 	n, err := r.reader.Discard(bytes)
 	r.offset += int64(n)
 	if err != nil {
 		return err
 	}
 
+	if r.validate {
+		if got, ok := r.ErrorCheck(); ok {
+			if want, ok := ComputeErrorCheck(r.header, r.data[FrameHeaderSize+2:]); ok && want != got {
+				return corruptError{&ErrChecksumMismatch{
+					Offset: r.offset - int64(len(r.data)),
+					Want:   want,
+					Got:    got,
+				}}
+			}
+		}
+	}
+
+	r.time += duration
+	r.samples += int64(sampleCount)
+	r.bits += int64(bytes) * 8
+	r.bitsTime += duration
+	if r.bitrates == nil {
+		r.bitrates = map[int]int{}
+		r.rate = rateHeader(r.header, r.data[FrameHeaderSize:])
+	}
+	bitrate, _ := r.header.Bitrate()
+	r.bitrates[bitrate]++
+	r.prev, r.hasPrev = r.last, r.hasLast
+	r.last, r.hasLast = r.header, true
+	if r.limited {
+		r.limit--
+	}
+
+	return nil
+}
+
+// sync discards data until the next syncword, returning the number of bytes
+// discarded. If strict is true, the syncword must be the start of a valid frame
+// followed by another one (see [Reader.StrictSync]), and if ref is not nil, the
+// frame must have the same version, layer, sampling frequency, and whether it
+// is free format as ref. The data is scanned in buffer-sized chunks, so the
+// syncword does not need to be within the buffer. If no syncword is found
+// before the end of the stream, all remaining data is discarded.
+func (r *Reader) sync(strict bool, ref *FrameHeader) (int64, error) {
+	var total int64
+	for {
+		if err := r.canceled(); err != nil {
+			return total, err
+		}
+		buf, err := r.reader.Peek(r.reader.Size())
+		if err != nil && err != io.EOF {
+			return total, err
+		}
+		eof := err == io.EOF
+		if r.end != -1 && int64(len(buf)) >= r.end-r.offset {
+			buf = buf[:max(r.end-r.offset, 0)]
+			eof = true
+		}
+		i, skip := findSync(buf, eof, strict, ref)
+		if i != -1 {
+			skip = i
+		}
+		n, err := r.reader.Discard(skip)
+		r.offset += int64(n)
+		total += int64(n)
+		if i != -1 || err != nil {
+			return total, err
+		}
+		if eof {
+			return total, ErrUnsynchronized
+		}
+	}
+}
+
+// findSync finds the index of the first acceptable syncword in b (see sync).
+// If none is found, -1 is returned along with the number of bytes which can be
+// discarded before trying again with more data.
+func findSync(b []byte, eof, strict bool, ref *FrameHeader) (int, int) {
+	for j := 0; j < len(b); j++ {
+		k := Sync(b[j:])
+		if k == -1 {
+			break
+		}
+		j += k
+		if !strict {
+			return j, 0
+		}
+		f, ok, more := probeFrame(b[j:], eof)
+		if ok && (ref == nil || sameFormat(f, *ref)) {
+			return j, 0
+		}
+		if more && j != 0 {
+			// check it again once it's at the start of the buffer
+			return -1, j
+		}
+	}
+	if eof {
+		return -1, len(b)
+	}
+	// the last byte could be the start of a syncword
+	return -1, max(len(b)-1, 0)
+}
+
+// syncSeek synchronizes after seeking to an arbitrary offset.
+func (r *Reader) syncSeek() error {
+	// we're probably in the middle of a frame, so false syncwords are likely
+	var ref *FrameHeader
+	if r.hasLast {
+		ref = &r.last
+	}
+	if _, err := r.sync(true, ref); err != nil {
+		return err
+	}
+	r.resync = false
+	return nil
+}
+
+// sameFormat checks if f and g have the same version, layer, sampling
+// frequency, and whether they are free format.
+func sameFormat(f, g FrameHeader) bool {
+	return f.ID == g.ID &&
+		f.Layer == g.Layer &&
+		f.SamplingFrequencyIndex == g.SamplingFrequencyIndex &&
+		(f.BitrateIndex == BitrateIndexFree) == (g.BitrateIndex == BitrateIndexFree)
+}
+
+// readTail checks for tags at the end of the underlying reader if it supports
+// random access, and sets the end of the audio data accordingly.
+func (r *Reader) readTail() error {
+	ra, ok := r.source.(io.ReaderAt)
+	if !ok {
+		return nil
+	}
+	size, ok, err := sourceSize(r.source)
+	if !ok || err != nil {
+		return err
+	}
+	// the offset passed to Reset may not be relative to the start of the
+	// source (e.g., if it is a slice of the stream starting at that offset)
+	r.base = r.offset
+	if sk, ok := r.source.(io.Seeker); ok {
+		cur, err := sk.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		r.base -= cur - int64(r.reader.Buffered())
+	}
+	end, id3v1, ape, err := readTailTags(ra, size)
+	if err != nil {
+		return err
+	}
+	r.end, r.id3v1, r.ape = r.base+end, id3v1, ape
 	return nil
 }
 
+// sourceSize gets the size of x if it has a Size method (like [bytes.Reader]
+// and [io.SectionReader]) or is an [io.Seeker].
+func sourceSize(x io.Reader) (int64, bool, error) {
+	switch x := x.(type) {
+	case interface{ Size() int64 }:
+		return x.Size(), true, nil
+	case io.Seeker:
+		cur, err := x.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false, err
+		}
+		size, err := x.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false, err
+		}
+		if _, err := x.Seek(cur, io.SeekStart); err != nil {
+			return 0, false, err
+		}
+		return size, true, nil
+	}
+	return 0, false, nil
+}
+
+// measureFree determines the number of slots (excluding padding) in free format
+// frames by finding the next syncword with the same fixed header fields as the
+// current frame.
+func (r *Reader) measureFree() (int, error) {
+	buf, err := r.reader.Peek(r.reader.Size())
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	i := syncFree(buf)
+	if i == -1 {
+		return 0, errors.New("could not determine free format frame size")
+	}
+	n, _, ok := r.header.SlotsFor(i)
+	if !ok {
+		return 0, errors.New("invalid free format frame size")
+	}
+	return n, nil
+}
+
+// All returns an iterator over the remaining frames. Iteration stops when an
+// error occurs, which can be retrieved with Err. Unlike [Reader.Frame], the
+// data of each yielded frame is not copied and may be overwritten on the next
+// iteration.
+func (r *Reader) All() iter.Seq[Frame] {
+	return func(yield func(Frame) bool) {
+		for r.Next() {
+			if !yield(Frame{Header: r.header, Data: r.data[FrameHeaderSize:]}) {
+				return
+			}
+		}
+	}
+}
+
+// Headers returns an iterator over the headers of the remaining frames.
+// Iteration stops when an error occurs, which can be retrieved with Err.
+func (r *Reader) Headers() iter.Seq[FrameHeader] {
+	return func(yield func(FrameHeader) bool) {
+		for r.Next() {
+			if !yield(r.header) {
+				return
+			}
+		}
+	}
+}
+
+// ID3v2Size returns the total size of the ID3v2 tags skipped at the start of
+// the stream.
+func (r *Reader) ID3v2Size() int64 {
+	return r.id3v2
+}
+
+// ID3v1 returns the ID3v1 tag at the end of the stream. It is only detected if
+// the underlying reader is an [io.ReaderAt] and its size can be determined. The
+// end of the audio data is considered to be the start of the tag.
+func (r *Reader) ID3v1() ([]byte, bool) {
+	return r.id3v1, r.id3v1 != nil
+}
+
+// APESize returns the total size of the APEv2 tag at the end of the stream
+// (before the ID3v1 tag, if any). It is only detected if the underlying reader
+// is an [io.ReaderAt] and its size can be determined. The end of the audio data
+// is considered to be the start of the tag.
+func (r *Reader) APESize() (int64, bool) {
+	return r.ape, r.ape != 0
+}
+
 // Offset gets the offset of the end of the current frame (i.e., the start of
 // the next frame).
 func (r *Reader) Offset() int64 {
 	return r.offset
 }
 
+// Buffered returns the number of bytes which have been read from the
+// underlying reader but not consumed yet (i.e., the lookahead available past
+// the end of the current frame).
+func (r *Reader) Buffered() int {
+	return r.reader.Buffered()
+}
+
+// Size returns the size of the buffer (see [NewReaderSize]).
+func (r *Reader) Size() int {
+	return r.reader.Size()
+}
+
 // FrameHeader returns the current frame header. It may be overwritten on the
 // next call to Next.
 func (r *Reader) Header() *FrameHeader {
 	return &r.header
 }
 
+// Padded returns true if the current frame contains the padding slot (i.e.,
+// the padding bit is set in the header).
+func (r *Reader) Padded() bool {
+	return r.data != nil && r.header.Padding
+}
+
+// Emphasis returns the emphasis of the current frame, which indicates the
+// de-emphasis required on playback.
+func (r *Reader) Emphasis() Emphasis {
+	return r.header.Emphasis
+}
+
+// FormatChanged returns true if the current frame is not [FrameHeader.Compatible]
+// with the previous one (e.g., the sampling frequency or number of channels
+// changed), which means a decoder needs to be reinitialized. It is false for
+// the first frame read after [Reader.Reset].
+func (r *Reader) FormatChanged() bool {
+	return r.data != nil && r.hasPrev && !r.header.Compatible(r.prev)
+}
+
+// Frame returns a copy of the current frame which remains valid after the next
+// call to Next.
+func (r *Reader) Frame() Frame {
+	var data []byte
+	if len(r.data) > FrameHeaderSize {
+		data = bytes.Clone(r.data[FrameHeaderSize:])
+	}
+	return Frame{
+		Header: r.header,
+		Data:   data,
+	}
+}
+
 // Raw returns the raw frame data including the header. It may be overwritten on
 // the next call to Next.
 func (r *Reader) Raw() []byte {
 	return r.data
 }
 
+// Data returns the frame data following the header and the parity-check word,
+// excluding the padding slot. It starts at offset 6 of the raw frame if the
+// protection bit is set in the header, or 4 otherwise. It may be overwritten on
+// the next call to Next.
+func (r *Reader) Data() []byte {
+	if r.data == nil {
+		return nil
+	}
+	start, end := FrameHeaderSize, len(r.data)
+	if r.header.Protection {
+		start += 2
+	}
+	if r.header.Padding {
+		if slotSize, ok := r.header.SlotSize(); ok {
+			end -= slotSize
+		}
+	}
+	if start > end {
+		return nil
+	}
+	return r.data[start:end]
+}
+
+// Padding returns the padding slot at the end of the frame. If the padding bit
+// in the header is not set, false is returned. It may be overwritten on the
+// next call to Next.
+func (r *Reader) Padding() ([]byte, bool) {
+	if !r.header.Padding {
+		return nil, false
+	}
+	slotSize, ok := r.header.SlotSize()
+	if !ok || len(r.data) < FrameHeaderSize+slotSize {
+		return nil, false
+	}
+	return r.data[len(r.data)-slotSize:], true
+}
+
+// MainDataBegin returns the Layer III main_data_begin of the current frame,
+// which is the number of bytes of main data from previous frames (i.e., from
+// the bit reservoir) which precede the main data for the current frame. If the
+// current frame is not Layer III, false is returned.
+func (r *Reader) MainDataBegin() (int, bool) {
+	if r.header.Layer != MPEGLayerIII {
+		return 0, false
+	}
+	si, err := ParseSideInfoIII(r.header, r.Data())
+	if err != nil {
+		return 0, false
+	}
+	return int(si.MainDataBegin), true
+}
+
+// LogicalMainData returns the Layer III main data for the current frame,
+// including the part stored in previous frames via the bit reservoir, and
+// excluding any ancillary data. If the current frame is not Layer III, or not
+// enough previous frames have been read, false is returned. It may be
+// overwritten on the next call to Next.
+func (r *Reader) LogicalMainData() ([]byte, bool) {
+	main, ok := r.mainData()
+	if !ok {
+		return nil, false
+	}
+	si, err := ParseSideInfoIII(r.header, r.Data())
+	if err != nil {
+		return nil, false
+	}
+	begin := int(si.MainDataBegin)
+	if begin > len(r.reservoir) {
+		return nil, false
+	}
+	r.logical = append(r.logical[:0], r.reservoir[len(r.reservoir)-begin:]...)
+	r.logical = append(r.logical, main...)
+	if n := si.mainDataSize(); n <= len(r.logical) {
+		return r.logical[:n], true
+	}
+	return nil, false
+}
+
+// Ancillary returns the data in the physical main data area of the current
+// Layer III frame following the end of its main data (e.g., a Xing header or
+// other encoder-specific data). Note that this may include main data for the
+// following frames via the bit reservoir. If the current frame is not Layer
+// III, or its side information is invalid, false is returned. It may be
+// overwritten on the next call to Next.
+func (r *Reader) Ancillary() ([]byte, bool) {
+	main, ok := r.mainData()
+	if !ok {
+		return nil, false
+	}
+	si, err := ParseSideInfoIII(r.header, r.Data())
+	if err != nil {
+		return nil, false
+	}
+	end := si.mainDataSize() - int(si.MainDataBegin)
+	if end > len(main) {
+		return nil, false
+	}
+	return main[max(end, 0):], true
+}
+
+// mainData returns the physical main data area (i.e., the data following the
+// side information) of the current Layer III frame.
+func (r *Reader) mainData() ([]byte, bool) {
+	if r.data == nil || r.header.Layer != MPEGLayerIII {
+		return nil, false
+	}
+	size, ok := r.header.SideInfoSize()
+	if !ok {
+		return nil, false
+	}
+	off := FrameHeaderSize + size
+	if r.header.Protection {
+		off += 2
+	}
+	if off > len(r.data) {
+		return nil, false
+	}
+	return r.data[off:], true
+}
+
+// fillReservoir adds the main data from the current frame to the bit
+// reservoir, keeping enough to satisfy the maximum main_data_begin.
+func (r *Reader) fillReservoir() {
+	main, ok := r.mainData()
+	if !ok {
+		r.reservoir = r.reservoir[:0]
+		return
+	}
+	r.reservoir = append(r.reservoir, main...)
+	if n := len(r.reservoir); n > maxMainDataBegin {
+		r.reservoir = r.reservoir[:copy(r.reservoir, r.reservoir[n-maxMainDataBegin:])]
+	}
+}
+
+// IsInfoFrame returns true if the current frame contains a Xing, Info, or VBRI
+// header rather than audio data. This is usually the first frame of a stream,
+// and it should not be included when counting frames or samples.
+func (r *Reader) IsInfoFrame() bool {
+	if len(r.data) < FrameHeaderSize {
+		return false
+	}
+	if _, ok := ParseXingHeader(r.header, r.data[FrameHeaderSize:]); ok {
+		return true
+	}
+	if _, ok := ParseVBRIHeader(r.header, r.data[FrameHeaderSize:]); ok {
+		return true
+	}
+	return false
+}
+
 // ErrorCheck returns the 16 bit parity-check word used for optional error
-// detection. If the protection flag in the header is not set, false is
-// returned.
+// detection. If the protection flag in the header is not set, or the frame is
+// too short to contain it, false is returned.
 func (r *Reader) ErrorCheck() (uint16, bool) {
-	if !r.header.Protection {
+	if !r.header.Protection || len(r.data) < FrameHeaderSize+2 {
 		return 0, false
 	}
 	return binary.BigEndian.Uint16(r.data[FrameHeaderSize : FrameHeaderSize+2]), true
@@ -199,5 +1262,120 @@ func (r *Reader) Time() time.Duration {
 	return r.time
 }
 
-// TODO: func (r *Reader) Padding() ([]byte, bool)
-// TODO: func (r *Reader) Data() []byte
+// AverageBitrate returns the average bitrate in kbit/s of all frames which have
+// been read, based on the actual size of the frames. If no frames have been
+// read, false is returned.
+func (r *Reader) AverageBitrate() (float64, bool) {
+	if r.bitsTime <= 0 {
+		return 0, false
+	}
+	return float64(r.bits) / r.bitsTime.Seconds() / 1000, true
+}
+
+// EffectiveBitrate returns the bitrate in kbit/s of the current frame. For free
+// format frames, it is derived from the measured frame size, rounded to the
+// nearest kbit/s. If no frame has been read, false is returned.
+func (r *Reader) EffectiveBitrate() (int, bool) {
+	if r.data == nil {
+		return 0, false
+	}
+	if r.header.BitrateIndex == BitrateIndexFree {
+		return r.header.freeBitrate(r.free)
+	}
+	return r.header.Bitrate()
+}
+
+// BitrateHistogram returns the number of frames read for each bitrate in
+// kbit/s. Free format frames are counted under 0.
+func (r *Reader) BitrateHistogram() map[int]int {
+	return maps.Clone(r.bitrates)
+}
+
+// RateMode is the bitrate mode of a stream.
+type RateMode int
+
+const (
+	RateUnknown RateMode = iota
+	RateCBR
+	RateVBR
+	RateABR
+)
+
+func (m RateMode) String() string {
+	switch m {
+	case RateUnknown:
+		return "unknown"
+	case RateCBR:
+		return "cbr"
+	case RateVBR:
+		return "vbr"
+	case RateABR:
+		return "abr"
+	default:
+		return strconv.Itoa(int(m))
+	}
+}
+
+// RateMode infers the bitrate mode of the stream. It returns [RateUnknown]
+// until the entire stream has been read without errors.
+//
+// If the first frame contains a LAME extension, its VBR method is used. If it
+// contains a Xing header (without a LAME extension indicating ABR) or a VBRI
+// header, the stream is VBR. If it contains an Info header, the stream is CBR.
+// Otherwise, the stream is CBR if every frame has the same bitrate, VBR if the
+// maximum bitrate is more than twice the minimum, and ABR otherwise.
+func (r *Reader) RateMode() RateMode {
+	if r.err != io.EOF || len(r.bitrates) == 0 {
+		return RateUnknown
+	}
+	if r.rate != RateUnknown {
+		return r.rate
+	}
+	if len(r.bitrates) == 1 {
+		return RateCBR
+	}
+	lo, hi := -1, -1
+	for bitrate := range r.bitrates {
+		if lo == -1 || bitrate < lo {
+			lo = bitrate
+		}
+		if hi == -1 || bitrate > hi {
+			hi = bitrate
+		}
+	}
+	if hi > 2*lo {
+		return RateVBR
+	}
+	return RateABR
+}
+
+// rateHeader gets the bitrate mode from the VBR header in the frame, if any.
+func rateHeader(h FrameHeader, body []byte) RateMode {
+	if l, ok := ParseLAMEHeader(h, body); ok {
+		switch l.VBRMethod {
+		case 1, 8:
+			return RateCBR
+		case 2, 9:
+			return RateABR
+		case 3, 4, 5, 6:
+			return RateVBR
+		}
+	}
+	if x, ok := ParseXingHeader(h, body); ok {
+		if x.IsCBR() {
+			return RateCBR
+		}
+		return RateVBR
+	}
+	if _, ok := ParseVBRIHeader(h, body); ok {
+		return RateVBR
+	}
+	return RateUnknown
+}
+
+// SamplePosition returns the number of samples (per channel) in all frames
+// which have been read. Since it is accumulated per frame, it remains correct
+// if the sampling frequency changes.
+func (r *Reader) SamplePosition() int64 {
+	return r.samples
+}
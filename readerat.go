@@ -0,0 +1,182 @@
+package mp3
+
+import (
+	"bytes"
+	"io"
+)
+
+// ReaderAt reads frames at arbitrary offsets in a stream. Unlike [Reader], it
+// does not keep any state between calls, so frames can be read in any order,
+// and from multiple goroutines concurrently.
+type ReaderAt struct {
+	r     io.ReaderAt
+	start int64 // of the audio data (after ID3v2 tags)
+	end   int64 // of the audio data (before ID3v1 and APEv2 tags)
+}
+
+// readerAtWindow is the number of bytes read before and after the offset
+// requested from a [ReaderAt], which is enough to fit two frames of any valid
+// stream.
+const readerAtWindow = 1 << 13
+
+// NewReaderAt creates a new ReaderAt reading from r, which is size bytes long.
+// Leading ID3v2 tags and trailing ID3v1 and APEv2 tags are excluded from the
+// audio data.
+func NewReaderAt(r io.ReaderAt, size int64) (*ReaderAt, error) {
+	end, _, _, err := readTailTags(r, size)
+	if err != nil {
+		return nil, err
+	}
+	ra := &ReaderAt{r: r, end: end}
+	buf := make([]byte, id3v2HeaderSize)
+	for ra.start+id3v2HeaderSize <= ra.end {
+		if _, err := r.ReadAt(buf, ra.start); err != nil {
+			return nil, err
+		}
+		n, ok := id3v2Size(buf)
+		if !ok {
+			break
+		}
+		ra.start += n
+	}
+	ra.start = min(ra.start, ra.end)
+	return ra, nil
+}
+
+// FrameAt reads the frame containing byteOffset, returning it along with the
+// offset of the next frame. If byteOffset is not within a frame (e.g., it is
+// within a tag or junk between frames), the next frame is returned instead. If
+// there are no more frames, [io.EOF] is returned.
+//
+// Since there is no information about previous frames, the frame is found by
+// following contiguous frames with the same format from the first syncword
+// within a fixed distance before byteOffset, which is reliable unless the
+// stream is corrupted. A frame found after byteOffset is only accepted if it is
+// followed by another one with the same version, layer, and sampling frequency,
+// or by the end of the audio data (see [Probe]). If no frame is found near
+// byteOffset, [ErrUnsynchronized] is returned.
+func (ra *ReaderAt) FrameAt(byteOffset int64) (Frame, int64, error) {
+	byteOffset = max(byteOffset, ra.start)
+	if byteOffset >= ra.end {
+		return Frame{}, 0, io.EOF
+	}
+	lo := max(byteOffset-readerAtWindow, ra.start)
+	hi := min(byteOffset+readerAtWindow, ra.end)
+	buf := make([]byte, hi-lo)
+	if n, err := ra.r.ReadAt(buf, lo); n != len(buf) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, 0, err
+	}
+	eof := hi == ra.end
+	cur := int(byteOffset - lo)
+
+	// the first chain of frames which reaches the offset, which must contain at
+	// least three frames (including the one following the offset) to reduce
+	// false positives unless it starts at the beginning of the audio data, and
+	// since identical frames can cause periodic false syncwords which look like
+	// free format frames, free format is only considered if nothing else is
+	// found
+	for _, free := range []bool{false, true} {
+		for i := 0; i <= cur; i++ {
+			j := Sync(buf[i : cur+1])
+			if j == -1 {
+				break
+			}
+			i += j
+			if f, start, size, n, ok := followFrames(buf[i:], cur-i, eof); ok && (f.BitrateIndex == BitrateIndexFree) == free && (n >= 3 || lo+int64(i) == ra.start) {
+				start += i
+				return readerAtFrame(f, buf[start:start+size]), lo + int64(start+size), nil
+			}
+		}
+	}
+
+	// otherwise, the next frame
+	for i := cur; i < len(buf); i++ {
+		j := Sync(buf[i:])
+		if j == -1 {
+			break
+		}
+		i += j
+		if f, size, ok := probeFrameSize(buf[i:], eof); ok {
+			return readerAtFrame(f, buf[i:i+size]), lo + int64(i+size), nil
+		}
+	}
+	if eof {
+		return Frame{}, 0, io.EOF
+	}
+	return Frame{}, 0, ErrUnsynchronized
+}
+
+// followFrames follows contiguous frames with the same format as the one at the
+// start of b (see sameFormat), returning the header, offset, and size of the
+// one containing the offset cur, and the number of frames followed, including
+// the one after it. The frame after it must also have the same format, unless
+// it is beyond the end of b, or at the end of b and eof is true. If the chain
+// is broken, false is returned.
+func followFrames(b []byte, cur int, eof bool) (f FrameHeader, start, size, n int, ok bool) {
+	var (
+		first FrameHeader
+		free  int
+	)
+	for start = 0; ; start += size {
+		if start > cur {
+			if start == len(b) && eof {
+				return f, start - size, size, n + 1, true
+			}
+			if start+FrameHeaderSize > len(b) && !eof {
+				return f, start - size, size, n, true // can't check it
+			}
+		}
+		if len(b)-start < FrameHeaderSize || !IsSyncword(b[start:]) {
+			return f, 0, 0, 0, false
+		}
+		var g FrameHeader
+		g.decode(b[start:])
+		if _, ok := g.Bitrate(); !ok {
+			return f, 0, 0, 0, false
+		}
+		if _, ok := g.SamplingFrequency(); !ok {
+			return f, 0, 0, 0, false
+		}
+		if start == 0 {
+			first = g
+		} else if !sameFormat(g, first) {
+			return f, 0, 0, 0, false
+		}
+		n++
+		if start > cur {
+			return f, start - size, size, n, true
+		}
+		f = g
+		if size, ok = frameSizeAt(f, b[start:], &free); !ok || start+size > len(b) {
+			return f, 0, 0, 0, false
+		}
+	}
+}
+
+// probeFrameSize is like probeFrame, but also returns the size of the frame.
+func probeFrameSize(b []byte, eof bool) (FrameHeader, int, bool) {
+	f, ok, _ := probeFrame(b, eof)
+	if !ok {
+		return f, 0, false
+	}
+	size, ok := f.FrameSize()
+	if !ok {
+		size = syncFree(b)
+	}
+	return f, min(size, len(b)), true
+}
+
+// readerAtFrame copies a raw frame.
+func readerAtFrame(f FrameHeader, raw []byte) Frame {
+	var data []byte
+	if len(raw) > FrameHeaderSize {
+		data = bytes.Clone(raw[FrameHeaderSize:])
+	}
+	return Frame{
+		Header: f,
+		Data:   data,
+	}
+}
@@ -0,0 +1,49 @@
+package mp3
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReaderAt(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var (
+			frames []Frame
+			starts []int64
+		)
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			frames = append(frames, r.Frame())
+			starts = append(starts, r.Offset()-int64(len(r.Raw())))
+		}
+		if r.Err() != nil {
+			t.Skipf("stream is invalid: %v", r.Err())
+		}
+		starts = append(starts, r.Offset())
+
+		ra, err := NewReaderAt(bytes.NewReader(buf), int64(len(buf)))
+		if err != nil {
+			t.Fatalf("create reader: %v", err)
+		}
+		for i, exp := range frames {
+			for _, off := range []int64{starts[i], (starts[i] + starts[i+1]) / 2, starts[i+1] - 1} {
+				act, next, err := ra.FrameAt(off)
+				if err != nil {
+					t.Fatalf("frame %d at %d: %v", i, off, err)
+				}
+				if act.Header != exp.Header || !bytes.Equal(act.Data, exp.Data) || next != starts[i+1] {
+					t.Fatalf("frame %d at %d: expected %s ending at %d, got %s ending at %d", i, off, exp.Header, starts[i+1], act.Header, next)
+				}
+			}
+		}
+		if len(frames) != 0 {
+			if _, _, err := ra.FrameAt(0); err != nil {
+				t.Errorf("first frame: %v", err)
+			}
+		}
+		if _, _, err := ra.FrameAt(starts[len(starts)-1]); err != io.EOF {
+			t.Errorf("expected EOF after the last frame, got %v", err)
+		}
+	})
+}
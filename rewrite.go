@@ -0,0 +1,49 @@
+package mp3
+
+import (
+	"io"
+	"strconv"
+)
+
+// ErrRewrite is returned by [RewritePrivateBits] when a frame cannot be
+// written.
+type ErrRewrite struct {
+	Frame int   // numbered from zero
+	Err   error // from the Writer
+}
+
+func (err *ErrRewrite) Error() string {
+	return "rewrite frame " + strconv.Itoa(err.Frame) + ": " + err.Err.Error()
+}
+
+func (err *ErrRewrite) Unwrap() error {
+	return err.Err
+}
+
+// RewritePrivateBits reads all frames from r using a [Reader] with the
+// specified buffer size, and writes them to w with the private bit of each
+// frame (numbered from zero) set to the result of pattern. If the frame is
+// protected and the private bit was changed, the parity-check word is
+// recomputed. Tags and other data between frames are not included.
+func RewritePrivateBits(w io.Writer, r io.Reader, pattern func(frame int) bool, buffer int) error {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return err
+	}
+	wr := NewWriter(w)
+	for frame := 0; rd.Next(); frame++ {
+		if h := *rd.Header(); h.Private != pattern(frame) {
+			h.Private = !h.Private
+			err = wr.WriteFrame(h, rd.Raw()[FrameHeaderSize:])
+		} else {
+			err = wr.WriteRaw(rd.Raw())
+		}
+		if err != nil {
+			return &ErrRewrite{Frame: frame, Err: err}
+		}
+	}
+	if err := rd.Err(); err != nil {
+		return err
+	}
+	return wr.Flush()
+}
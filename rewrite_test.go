@@ -0,0 +1,81 @@
+package mp3
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestRewritePrivateBits(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var frames []Frame
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			frames = append(frames, r.Frame())
+		}
+		if err := r.Err(); err != nil {
+			t.Skipf("stream is invalid: %v", err)
+		}
+
+		pattern := func(frame int) bool {
+			return frame%3 == 0
+		}
+		var out bytes.Buffer
+		if err := RewritePrivateBits(&out, bytes.NewReader(buf), pattern, 16384); err != nil {
+			t.Fatalf("rewrite: %v", err)
+		}
+
+		var n int
+		r = NewReader(bytes.NewReader(out.Bytes()), 16384)
+		r.ValidateChecksum(true)
+		for ; r.Next(); n++ {
+			exp := frames[n]
+			exp.Header.Private = pattern(n)
+			if act := r.Frame(); act.Header != exp.Header {
+				t.Errorf("frame %d: expected header %s, got %s", n, exp.Header, act.Header)
+			} else if !bytes.Equal(act.Data[2:], exp.Data[2:]) || (!exp.Header.Protection && !bytes.Equal(act.Data, exp.Data)) {
+				t.Errorf("frame %d: data changed", n)
+			}
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("read rewritten frames: %v", err)
+		}
+		if n != len(frames) {
+			t.Errorf("expected %d frames, got %d", len(frames), n)
+		}
+	})
+}
+
+// limitWriter fails with err after writing n bytes.
+type limitWriter struct {
+	n   int
+	err error
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if len(b) > w.n {
+		return 0, w.err
+	}
+	w.n -= len(b)
+	return len(b), nil
+}
+
+func TestRewritePrivateBitsError(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer1/fl1.mp1")
+	if err != nil {
+		panic(err)
+	}
+	fail := errors.New("fail")
+	r := NewReader(bytes.NewReader(buf), 16384)
+	var size int
+	for range 3 {
+		r.Next()
+		size += len(r.Raw())
+	}
+	err = RewritePrivateBits(&limitWriter{size, fail}, bytes.NewReader(buf), func(int) bool { return true }, 16384)
+	var rerr *ErrRewrite
+	if !errors.As(err, &rerr) || rerr.Frame != 3 || !errors.Is(err, fail) {
+		t.Errorf("expected error for frame 3, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package mp3
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// SeekToTime seeks to the frame containing d (as measured by [Reader.Time]
+// when reading the stream sequentially), so that after it returns, Time is at
+// or just before d and the next call to Next reads that frame. The underlying
+// reader must be an [io.Seeker] (see [Reader.Seek]).
+//
+// If the stream appears to be CBR (i.e., the first frame contains an Info
+// header or no VBR header at all, and the frame at the computed position has
+// the same bitrate), the position is computed from the bitrate and sampling
+// frequency. Otherwise, since the table of contents of a Xing or VBRI header
+// only gives an approximate position, the exact frame is found by reading only
+// the header of each frame from the start. If the headers cannot be followed
+// (e.g., there is invalid data between frames), the stream is read from the
+// start.
+func (r *Reader) SeekToTime(d time.Duration) error {
+	if d < 0 {
+		d = 0
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r.time, r.samples = 0, 0
+	if !r.Next() {
+		return r.Err()
+	}
+	h, first := r.header, r.offset-int64(len(r.data))
+	body := r.data[FrameHeaderSize:]
+
+	if _, ok := ParseXingHeader(h, body); ok && !r.IsInfoFrame() {
+		return r.seekStart(first, d)
+	}
+	if x, ok := ParseXingHeader(h, body); ok && !x.IsCBR() {
+		return r.seekStart(first, d)
+	}
+	if _, ok := ParseVBRIHeader(h, body); ok {
+		return r.seekStart(first, d)
+	}
+	dur, ok := h.Duration()
+	if !ok || dur <= 0 {
+		return r.seekStart(first, d)
+	}
+	bitrate, ok := h.Bitrate()
+	if !ok || bitrate == 0 {
+		return r.seekStart(first, d)
+	}
+	target := int64(d / dur) // frames before the one containing d
+	offset, frame := cbrSeek(h, bitrate, first, target)
+
+	// start a bit earlier so we end up at or before the target frame
+	if size, ok := h.FrameSize(); ok {
+		offset -= int64(size) / 2
+	}
+	if offset < first {
+		offset = first
+	}
+	if err := r.seekFrame(offset, d, frame); err == nil && r.time <= d {
+		return nil
+	}
+	return r.seekStart(first, d)
+}
+
+// seekStart seeks to the frame containing d, counting from the first frame at
+// offset first, by following the frame headers if possible, or reading all
+// frames otherwise.
+func (r *Reader) seekStart(first int64, d time.Duration) error {
+	if ok, err := r.seekHeaders(first, d); ok || err != nil {
+		return err
+	}
+	return r.seekFrame(first, d, nil)
+}
+
+// seekHeaders seeks to the frame containing d, counting from the first frame at
+// offset first, by reading only the header of each frame from the underlying
+// reader. If the frames are not contiguous until the frame containing d or the
+// end of the audio data, or a free format frame size has not been measured
+// yet, false is returned.
+func (r *Reader) seekHeaders(first int64, d time.Duration) (bool, error) {
+	var ra io.ReaderAt
+	switch x := r.source.(type) {
+	case io.ReaderAt:
+		ra = x
+	case io.ReadSeeker:
+		ra = seekReaderAt{x}
+	default:
+		return false, nil
+	}
+	var (
+		buf     [FrameHeaderSize]byte
+		off     = first
+		t       time.Duration
+		samples int64
+		free    = r.free
+	)
+	for {
+		if r.end != -1 && off >= r.end {
+			break // end of the audio data
+		}
+		n, err := ra.ReadAt(buf[:], off)
+		if n == 0 && err == io.EOF {
+			break // end of the stream
+		}
+		if err != nil && (err != io.EOF || n != len(buf)) {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return false, nil // trailing data
+			}
+			return false, err
+		}
+		if !IsSyncword(buf[:]) {
+			return false, nil
+		}
+		var g FrameHeader
+		g.decode(buf[:])
+		if checkHeader(g) != nil {
+			return false, nil
+		}
+		size, ok := frameSizeAt(g, nil, &free)
+		if !ok {
+			return false, nil
+		}
+		dur, ok := g.Duration()
+		if !ok || t+dur > d {
+			break
+		}
+		sampleCount, _ := g.SampleCount()
+		off += int64(size)
+		t += dur
+		samples += int64(sampleCount)
+	}
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		return false, err
+	}
+	r.resync = false // the offset is the start of a frame
+	r.time, r.samples = t, samples
+	return true, nil
+}
+
+// errSeekEstimate is returned by seekFrame if the number of frames before the
+// one at the offset cannot be estimated.
+var errSeekEstimate = errors.New("cannot estimate position")
+
+// seekFrame seeks to the first frame at or after offset, sets the time and
+// sample position based on the number of frames before it (as determined by
+// frame from the start and header of the frame, or zero if nil), then reads
+// frames until the next one contains d. The stream is assumed to have a
+// constant sampling frequency and number of samples per frame.
+func (r *Reader) seekFrame(offset int64, d time.Duration, frame func(start int64, g FrameHeader) (int64, bool)) error {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if err := r.syncSeek(); err != nil {
+		return err
+	}
+	var n int64
+	if frame != nil {
+		buf, err := r.reader.Peek(FrameHeaderSize)
+		if err != nil {
+			return err
+		}
+		var g FrameHeader
+		g.decode(buf)
+		var ok bool
+		if n, ok = frame(r.offset, g); !ok {
+			return errSeekEstimate
+		}
+	}
+	dur, _ := r.last.Duration()
+	sampleCount, _ := r.last.SampleCount()
+	r.time, r.samples = time.Duration(n)*dur, n*int64(sampleCount)
+
+	for {
+		buf, err := r.reader.Peek(FrameHeaderSize)
+		if err != nil || !IsSyncword(buf) {
+			return nil
+		}
+		var g FrameHeader
+		g.decode(buf)
+		if dur, ok := g.Duration(); !ok || r.time+dur > d {
+			return nil
+		}
+		if !r.Next() {
+			return r.Err()
+		}
+	}
+}
+
+// cbrSeek gets the approximate offset of the start of the specified frame of a
+// CBR stream, and a function to get the frame number from the start and header
+// of a frame. Since the stream may actually be VBR without a header, frames
+// with a different bitrate from the first one are rejected.
+func cbrSeek(h FrameHeader, bitrate int, first, target int64) (int64, func(int64, FrameHeader) (int64, bool)) {
+	sampleCount, _ := h.SampleCount()
+	samplingFrequency, _ := h.SamplingFrequency()
+	size := float64(bitrate) * 1000 / 8 * float64(sampleCount) / float64(samplingFrequency)
+	return first + int64(float64(target)*size), func(start int64, g FrameHeader) (int64, bool) {
+		if g.BitrateIndex != h.BitrateIndex {
+			return 0, false
+		}
+		return int64(math.Round(float64(start-first) / size)), true
+	}
+}
@@ -0,0 +1,157 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestSeekToTime(t *testing.T) {
+	for _, name := range []string{
+		"testdata/layer1/fl1.mp1",
+		"testdata/layer2/fl10.mp2",
+		"testdata/layer3/he_32khz.mp3",
+		"testdata/layer3/he_44khz.mp3",
+		"testdata/layer3/he_free.mp3",
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf, err := fs.ReadFile(testdata, name)
+			if err != nil {
+				panic(err)
+			}
+			testSeekToTime(t, buf)
+		})
+	}
+	t.Run("xing", func(t *testing.T) {
+		buf, _ := testVBR(false)
+		testSeekToTime(t, buf)
+	})
+	t.Run("vbri", func(t *testing.T) {
+		buf, _ := testVBR(true)
+		testSeekToTime(t, buf)
+	})
+}
+
+// testSeekToTime checks that SeekToTime is consistent with reading the stream
+// sequentially.
+func testSeekToTime(t *testing.T, buf []byte) {
+	var (
+		starts []int64
+		times  []time.Duration
+	)
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		starts = append(starts, r.Offset()-int64(len(r.Raw())))
+		times = append(times, r.Time()-mustDuration(r.Header()))
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	total := r.Time()
+
+	r = NewReader(bytes.NewReader(buf), 16384)
+	for _, d := range []time.Duration{
+		0,
+		times[1],
+		times[1] - 1,
+		times[len(times)/3] + 1,
+		total / 2,
+		times[len(times)-1],
+		total - 1,
+		total,
+		total + time.Second,
+	} {
+		if err := r.SeekToTime(d); err != nil {
+			t.Fatalf("seek to %s: %v", d, err)
+		}
+		if r.Time() > d {
+			t.Errorf("seek to %s: time %s is after target", d, r.Time())
+		}
+		if d < total {
+			if !r.Next() {
+				t.Fatalf("seek to %s: read frame: %v", d, r.Err())
+			}
+			start := r.Offset() - int64(len(r.Raw()))
+			i := 0
+			for i < len(starts) && starts[i] < start {
+				i++
+			}
+			if i == len(starts) || starts[i] != start {
+				t.Fatalf("seek to %s: not at the start of a frame (%d)", d, start)
+			}
+			if act, exp := r.Time()-mustDuration(r.Header()), times[i]; act != exp {
+				t.Errorf("seek to %s: expected time %s for frame %d, got %s", d, exp, i, act)
+			}
+			if exp := times[i]; exp > d || (i+1 < len(times) && times[i+1] <= d) {
+				t.Errorf("seek to %s: expected frame containing target, got frame %d at %s", d, i, exp)
+			}
+		} else {
+			if act, exp := r.Time(), total; act != exp {
+				t.Errorf("seek to %s: expected time %s at end, got %s", d, exp, act)
+			}
+			if r.Next() {
+				t.Errorf("seek to %s: expected no frames after end", d)
+			}
+		}
+	}
+}
+
+func mustDuration(h *FrameHeader) time.Duration {
+	d, ok := h.Duration()
+	if !ok {
+		panic("invalid header")
+	}
+	return d
+}
+
+// testVBR builds a VBR stream with a Xing or VBRI header, also returning the
+// duration of each frame.
+func testVBR(vbri bool) ([]byte, time.Duration) {
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+
+	var audio []byte
+	var starts []int
+	for i := range 400 {
+		g := h
+		g.BitrateIndex = BitrateIndex(1 + i*7%14)
+		size, _ := g.FrameSize()
+		frame := make([]byte, size)
+		g.encode(frame)
+		starts = append(starts, len(audio))
+		audio = append(audio, frame...)
+	}
+
+	var info []byte
+	if vbri {
+		const fpe = 10
+		size, _ := h.FrameSize()
+		info = make([]byte, size)
+		h.encode(info)
+		b := info[FrameHeaderSize+vbriOffset:]
+		copy(b, "VBRI")
+		binary.BigEndian.PutUint32(b[10:], uint32(len(info)+len(audio)))
+		binary.BigEndian.PutUint32(b[14:], uint32(len(starts)))
+		binary.BigEndian.PutUint16(b[18:], uint16(len(starts)/fpe))
+		binary.BigEndian.PutUint16(b[20:], 1)
+		binary.BigEndian.PutUint16(b[22:], 2)
+		binary.BigEndian.PutUint16(b[24:], fpe)
+		for i := range len(starts) / fpe {
+			end := len(audio)
+			if j := (i + 1) * fpe; j < len(starts) {
+				end = starts[j]
+			}
+			binary.BigEndian.PutUint16(b[26+i*2:], uint16(end-starts[i*fpe]))
+		}
+	} else {
+		x := XingHeader{Tag: "Xing", Flags: XingFrames | XingBytes | XingTOC, Frames: uint32(len(starts))}
+		size, _ := h.FrameSize()
+		x.Bytes = uint32(size + len(audio))
+		for i := range x.TOC {
+			x.TOC[i] = byte((size + starts[i*len(starts)/100]) * 256 / int(x.Bytes))
+		}
+		info = testInfoFrame(h, x, nil)
+	}
+	return append(info, audio...), mustDuration(&h)
+}
@@ -0,0 +1,92 @@
+package mp3
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Split reads all frames from r using a [Reader] with the specified buffer size,
+// and splits them at the frame boundaries nearest to each of the specified
+// times, which must be in ascending order, returning len(at)+1 streams. If
+// multiple times are nearest to the same boundary, or are past the end of the
+// stream, the corresponding streams will be empty. Tags and the Xing, Info, or
+// VBRI frame (which would be incorrect for the split streams) are not included.
+//
+// For Layer III, the first frame of a split stream may depend on main data from
+// the preceding frames (i.e., the bit reservoir). In this case, silent frames
+// containing the main data are inserted before it so it can be decoded, which
+// adds the duration of those frames to the start of the stream.
+func Split(r io.Reader, at []time.Duration, buffer int) ([][]byte, error) {
+	for i := 1; i < len(at); i++ {
+		if at[i] < at[i-1] {
+			return nil, errors.New("split times must be in ascending order")
+		}
+	}
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		streams = make([][]byte, 1, len(at)+1)
+		t       time.Duration
+	)
+	for rd.Next() {
+		if rd.IsInfoFrame() {
+			continue
+		}
+		duration, _ := rd.Header().Duration()
+		for len(streams) <= len(at) && 2*(at[len(streams)-1]-t) < duration {
+			streams = append(streams, nil)
+		}
+		cur := &streams[len(streams)-1]
+		if len(*cur) == 0 && len(streams) > 1 {
+			if begin, ok := rd.MainDataBegin(); ok && begin != 0 {
+				reservoir := rd.reservoir[max(len(rd.reservoir)-begin, 0):]
+				if *cur, err = primingFrames(*cur, *rd.Header(), len(rd.Raw()), begin, reservoir); err != nil {
+					return nil, err
+				}
+			}
+		}
+		*cur = append(*cur, rd.Raw()...)
+		t += duration
+	}
+	if err := rd.Err(); err != nil {
+		return nil, err
+	}
+	for len(streams) <= len(at) {
+		streams = append(streams, nil)
+	}
+	return streams, nil
+}
+
+// primingFrames appends silent Layer III frames with the same header and size
+// as a frame with the specified main_data_begin, which do not depend on the bit
+// reservoir themselves, and which contain the main data (zero-padded to begin
+// bytes) from the bit reservoir required by that frame.
+func primingFrames(dst []byte, h FrameHeader, size, begin int, reservoir []byte) ([]byte, error) {
+	sideInfoSize, ok := h.SideInfoSize()
+	if !ok {
+		return dst, errors.New("not a valid layer 3 frame")
+	}
+	off := FrameHeaderSize + sideInfoSize
+	if h.Protection {
+		off += 2
+	}
+	capacity := size - off
+	if capacity <= 0 {
+		return dst, errors.New("frame too short for main data")
+	}
+	n := (begin + capacity - 1) / capacity
+	main := make([]byte, n*capacity)
+	copy(main[len(main)-len(reservoir):], reservoir)
+	for i := range n {
+		body := make([]byte, size-FrameHeaderSize)
+		copy(body[off-FrameHeaderSize:], main[i*capacity:])
+		var err error
+		if dst, err = h.AppendFrame(dst, body); err != nil {
+			return dst, err
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,100 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	for _, name := range []string{
+		"testdata/layer2/fl10.mp2",
+		"testdata/layer3/he_44khz.mp3",
+		"testdata/layer3/he_free.mp3",
+		"testdata/layer3/si.mp3",
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf, err := fs.ReadFile(testdata, name)
+			if err != nil {
+				panic(err)
+			}
+
+			var (
+				frames [][]byte
+				mains  [][]byte
+				times  []time.Duration
+			)
+			r := NewReader(bytes.NewReader(buf), 16384)
+			for r.Next() {
+				main, _ := r.LogicalMainData()
+				frames = append(frames, bytes.Clone(r.Raw()))
+				mains = append(mains, bytes.Clone(main))
+				times = append(times, r.Time()-mustDuration(r.Header()))
+			}
+			if err := r.Err(); err != nil {
+				t.Fatalf("read frames: %v", err)
+			}
+
+			d := mustDuration(r.Header())
+			layer3 := r.Header().Layer == MPEGLayerIII
+			at := []time.Duration{
+				times[3] - d/4,         // nearest to the start of frame 3
+				times[10] + d/4,        // nearest to the start of frame 10
+				times[10] + d/3,        // same as the previous one
+				times[len(times)/2],    // exact
+				r.Time() + time.Second, // past the end
+			}
+			cuts := []int{0, 3, 10, 10, len(times) / 2, len(times), len(times)}
+
+			streams, err := Split(bytes.NewReader(buf), at, 16384)
+			if err != nil {
+				t.Fatalf("split: %v", err)
+			}
+			if len(streams) != len(at)+1 {
+				t.Fatalf("expected %d streams, got %d", len(at)+1, len(streams))
+			}
+			for i, stream := range streams {
+				exp := frames[cuts[i]:cuts[i+1]]
+				if len(exp) == 0 {
+					if len(stream) != 0 {
+						t.Errorf("stream %d: expected no frames, got %d bytes", i, len(stream))
+					}
+					continue
+				}
+				var act [][]byte
+				var main [][]byte
+				r := NewReader(bytes.NewReader(stream), 16384)
+				for r.Next() {
+					m, ok := r.LogicalMainData()
+					if r.Header().Layer == MPEGLayerIII && !ok {
+						t.Errorf("stream %d: frame %d: missing main data", i, len(act))
+					}
+					act = append(act, bytes.Clone(r.Raw()))
+					main = append(main, bytes.Clone(m))
+				}
+				if err := r.Err(); err != nil {
+					t.Fatalf("stream %d: read frames: %v", i, err)
+				}
+				if len(act) < len(exp) {
+					t.Fatalf("stream %d: expected at least %d frames, got %d", i, len(exp), len(act))
+				}
+				priming := len(act) - len(exp)
+				if (i == 0 || !layer3) && priming != 0 {
+					t.Errorf("stream %d: expected no priming frames, got %d", i, priming)
+				}
+				for j := range exp {
+					if !bytes.Equal(act[priming+j], exp[j]) {
+						t.Errorf("stream %d: frame %d: incorrect data", i, j)
+					}
+					if i != 0 && !bytes.Equal(main[priming+j], mains[cuts[i]+j]) {
+						t.Errorf("stream %d: frame %d: incorrect main data", i, j)
+					}
+				}
+			}
+		})
+	}
+	if _, err := Split(bytes.NewReader(nil), []time.Duration{2, 1}, 16384); err == nil {
+		t.Errorf("expected error for unordered times")
+	}
+}
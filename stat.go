@@ -0,0 +1,84 @@
+package mp3
+
+import (
+	"io"
+	"time"
+)
+
+// Stat summarizes a stream.
+type Stat struct {
+	Frames      int           // excluding the Xing, Info, or VBRI frame
+	Duration    time.Duration // of all frames
+	FirstHeader FrameHeader   // of the first frame
+	RateMode    RateMode      // see [Reader.RateMode]
+	AvgBitrate  float64       // in kbit/s, based on the actual size of the frames
+	SampleRate  int           // of the first frame, in Hz
+	Channels    int           // of the first frame
+	Emphasis    Emphasis      // of the first frame (see [Reader.Emphasis])
+	HasXing     bool          // Xing or Info header
+	HasLAME     bool          // LAME extension following the Xing header
+	HasID3v2    bool
+	HasID3v1    bool
+}
+
+// StatReader reads all frames from r, which is size bytes long, and summarizes
+// them. If an error occurs, the summary of the frames read so far is returned
+// along with it.
+func StatReader(r io.ReaderAt, size int64) (Stat, error) {
+	var (
+		st   Stat
+		bits int64
+	)
+	rd := NewReader(io.NewSectionReader(r, 0, size), indexBufferSize)
+	for first := true; rd.Next(); first = false {
+		h := *rd.Header()
+		if first && rd.IsInfoFrame() {
+			body := rd.Raw()[FrameHeaderSize:]
+			if _, ok := ParseXingHeader(h, body); ok {
+				st.HasXing = true
+				_, st.HasLAME = ParseLAMEHeader(h, body)
+			}
+			continue
+		}
+		if st.Frames == 0 {
+			st.FirstHeader = h
+			st.SampleRate, _ = h.SamplingFrequency()
+			st.Channels = h.ChannelCount()
+			st.Emphasis = h.Emphasis
+		}
+		duration, _ := h.Duration()
+		st.Frames++
+		st.Duration += duration
+		bits += int64(len(rd.Raw())) * 8
+	}
+	st.RateMode = rd.RateMode()
+	if st.Duration > 0 {
+		st.AvgBitrate = float64(bits) / st.Duration.Seconds() / 1000
+	}
+	st.HasID3v2 = rd.ID3v2Size() != 0
+	_, st.HasID3v1 = rd.ID3v1()
+	return st, rd.Err()
+}
+
+// SurveyFormats reads all frames from r using a [Reader] with the specified
+// buffer size, counting the frames with each sampling frequency (in Hz), mode,
+// and layer. A well-formed stream only has one sampling frequency and layer. If
+// an error occurs, the counts for the frames read so far are returned along
+// with it.
+func SurveyFormats(r io.Reader, buffer int) (sampleRates map[int]int, modes map[Mode]int, layers map[MPEGLayer]int, err error) {
+	rd, err := NewReaderSize(r, buffer)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sampleRates = map[int]int{}
+	modes = map[Mode]int{}
+	layers = map[MPEGLayer]int{}
+	for rd.Next() {
+		h := rd.Header()
+		sampleRate, _ := h.SamplingFrequency()
+		sampleRates[sampleRate]++
+		modes[h.Mode]++
+		layers[h.Layer]++
+	}
+	return sampleRates, modes, layers, rd.Err()
+}
@@ -0,0 +1,138 @@
+package mp3
+
+import (
+	"bytes"
+	"io/fs"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestStatReader(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer1/fl1.mp1")
+	if err != nil {
+		panic(err)
+	}
+	var n int
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		n++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+
+	st, err := StatReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if st.Frames != n || st.Duration != r.Time() || st.RateMode != RateCBR || math.Abs(st.AvgBitrate-384) > 0.001 {
+		t.Errorf("incorrect stat %+v", st)
+	}
+	if st.SampleRate != 32000 || st.Channels != 2 || st.FirstHeader.Layer != MPEGLayerI {
+		t.Errorf("incorrect format %+v", st)
+	}
+	if st.HasXing || st.HasLAME || st.HasID3v2 || st.HasID3v1 {
+		t.Errorf("expected no headers or tags, got %+v", st)
+	}
+	if st.Emphasis != EmphasisNone {
+		t.Errorf("expected no emphasis, got %s", st.Emphasis)
+	}
+
+	audio, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(audio[:FrameHeaderSize]); err != nil {
+		panic(err)
+	}
+	h.BitrateIndex = 9
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	buf = slices.Concat([]byte("ID3\x04\x00\x00\x00\x00\x00\x02\x00\x00"), testInfoFrame(h, XingHeader{Tag: "Xing"}, testLAME), audio, tag)
+
+	st, err = StatReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !st.HasXing || !st.HasLAME || !st.HasID3v2 || !st.HasID3v1 {
+		t.Errorf("expected headers and tags, got %+v", st)
+	}
+	if st.FirstHeader.BitrateIndex == 9 || st.RateMode != RateVBR || st.SampleRate != 44100 || st.Channels != 1 {
+		t.Errorf("incorrect stat %+v", st)
+	}
+	if exp, err := StatReader(bytes.NewReader(audio), int64(len(audio))); err != nil {
+		t.Fatalf("stat: %v", err)
+	} else if st.Frames != exp.Frames || st.Duration != exp.Duration || st.AvgBitrate != exp.AvgBitrate {
+		t.Errorf("expected the info frame to be excluded, got %+v", st)
+	}
+}
+
+func TestStatEmphasis(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	buf = bytes.Clone(buf)
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		buf[r.Offset()-int64(len(r.Raw()))+3] |= byte(EmphasisCCITT_J_17)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("read frames: %v", err)
+	}
+	st, err := StatReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if st.Emphasis != EmphasisCCITT_J_17 {
+		t.Errorf("expected emphasis %s, got %s", EmphasisCCITT_J_17, st.Emphasis)
+	}
+}
+
+func TestSurveyFormats(t *testing.T) {
+	for _, tc := range []struct {
+		Name        string
+		SampleRates []int
+		Modes       []Mode
+		Layers      []MPEGLayer
+	}{
+		{"testdata/layer1/fl1.mp1", []int{32000}, []Mode{ModeStereo, ModeJointStereo}, []MPEGLayer{MPEGLayerI}},
+		{"testdata/layer3/he_44khz.mp3", []int{44100}, []Mode{ModeSingleChannel}, []MPEGLayer{MPEGLayerIII}},
+		{"testdata/layer3/he_mode.mp3", []int{44100}, []Mode{ModeStereo, ModeJointStereo, ModeDualChannel, ModeSingleChannel}, []MPEGLayer{MPEGLayerIII}},
+	} {
+		buf, err := fs.ReadFile(testdata, tc.Name)
+		if err != nil {
+			panic(err)
+		}
+		n, _, err := CountFrames(bytes.NewReader(buf), 16384)
+		if err != nil {
+			t.Fatalf("%s: count frames: %v", tc.Name, err)
+		}
+		sampleRates, modes, layers, err := SurveyFormats(bytes.NewReader(buf), 16384)
+		if err != nil {
+			t.Fatalf("%s: survey formats: %v", tc.Name, err)
+		}
+		if act := slices.Sorted(maps.Keys(sampleRates)); !slices.Equal(act, tc.SampleRates) {
+			t.Errorf("%s: expected sampling frequencies %v, got %v", tc.Name, tc.SampleRates, act)
+		}
+		if act := slices.Sorted(maps.Keys(modes)); !slices.Equal(act, tc.Modes) {
+			t.Errorf("%s: expected modes %v, got %v", tc.Name, tc.Modes, act)
+		}
+		if act := slices.Sorted(maps.Keys(layers)); !slices.Equal(act, tc.Layers) {
+			t.Errorf("%s: expected layers %v, got %v", tc.Name, tc.Layers, act)
+		}
+		var total int
+		for _, c := range modes {
+			total += c
+		}
+		if total != n {
+			t.Errorf("%s: expected %d frames, got %d", tc.Name, n, total)
+		}
+	}
+	if _, _, _, err := SurveyFormats(bytes.NewReader(nil), 0); err == nil {
+		t.Errorf("expected error for invalid buffer size")
+	}
+}
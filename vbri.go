@@ -0,0 +1,65 @@
+package mp3
+
+import "encoding/binary"
+
+// VBRIHeader is the VBR header written by the Fraunhofer encoder at a fixed
+// offset in the first frame of a stream.
+type VBRIHeader struct {
+	Version uint16
+	Delay   uint16
+	Quality uint16
+	// Bytes is the size of the stream in bytes.
+	Bytes uint32
+	// Frames is the number of frames in the stream.
+	Frames uint32
+	// TOC contains the size of each segment of TOCFramesPerEntry frames in
+	// units of TOCScale bytes.
+	TOC               []uint32
+	TOCScale          uint16
+	TOCFramesPerEntry uint16
+}
+
+// vbriOffset is the offset of the VBRI header from the end of the frame header.
+const vbriOffset = 32
+
+// ParseVBRIHeader parses the VBRI header from the frame data following the
+// header. If the frame does not contain a valid VBRI header, false is
+// returned.
+func ParseVBRIHeader(f FrameHeader, body []byte) (*VBRIHeader, bool) {
+	if f.Layer != MPEGLayerIII || len(body) < vbriOffset+26 {
+		return nil, false
+	}
+	b := body[vbriOffset:]
+	if string(b[:4]) != "VBRI" {
+		return nil, false
+	}
+	v := &VBRIHeader{
+		Version:           binary.BigEndian.Uint16(b[4:]),
+		Delay:             binary.BigEndian.Uint16(b[6:]),
+		Quality:           binary.BigEndian.Uint16(b[8:]),
+		Bytes:             binary.BigEndian.Uint32(b[10:]),
+		Frames:            binary.BigEndian.Uint32(b[14:]),
+		TOCScale:          binary.BigEndian.Uint16(b[20:]),
+		TOCFramesPerEntry: binary.BigEndian.Uint16(b[24:]),
+	}
+	var (
+		entries = int(binary.BigEndian.Uint16(b[18:]))
+		size    = int(binary.BigEndian.Uint16(b[22:]))
+	)
+	if size < 1 || size > 4 {
+		return nil, false
+	}
+	b = b[26:]
+	if len(b) < entries*size {
+		return nil, false
+	}
+	v.TOC = make([]uint32, entries)
+	for i := range v.TOC {
+		var x uint32
+		for _, c := range b[i*size : (i+1)*size] {
+			x = x<<8 | uint32(c)
+		}
+		v.TOC[i] = x
+	}
+	return v, true
+}
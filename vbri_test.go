@@ -0,0 +1,43 @@
+package mp3
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseVBRIHeader(t *testing.T) {
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeStereo}
+	body := make([]byte, 413)
+	copy(body[vbriOffset:], []byte{
+		'V', 'B', 'R', 'I',
+		0x00, 0x01, // version
+		0x04, 0xB0, // delay
+		0x00, 0x4B, // quality
+		0x00, 0x01, 0x02, 0x03, // bytes
+		0x00, 0x00, 0x01, 0x00, // frames
+		0x00, 0x03, // toc entries
+		0x00, 0x02, // toc scale
+		0x00, 0x03, // toc entry size
+		0x00, 0x10, // toc frames per entry
+		0x00, 0x00, 0x01,
+		0x00, 0x01, 0x00,
+		0x01, 0x00, 0x00,
+	})
+	v, ok := ParseVBRIHeader(h, body)
+	if !ok {
+		t.Fatalf("failed to parse vbri header")
+	}
+	if v.Version != 1 || v.Delay != 1200 || v.Quality != 75 || v.Bytes != 0x010203 || v.Frames != 256 || v.TOCScale != 2 || v.TOCFramesPerEntry != 16 {
+		t.Errorf("incorrect vbri header %+v", v)
+	}
+	if exp := []uint32{1, 256, 65536}; !slices.Equal(v.TOC, exp) {
+		t.Errorf("expected toc %v, got %v", exp, v.TOC)
+	}
+	if _, ok := ParseVBRIHeader(h, body[:vbriOffset+26+8]); ok {
+		t.Errorf("expected truncated toc to fail")
+	}
+	body[vbriOffset] = 'X'
+	if _, ok := ParseVBRIHeader(h, body); ok {
+		t.Errorf("expected invalid magic to fail")
+	}
+}
@@ -0,0 +1,48 @@
+package mp3
+
+import "io"
+
+// Writer writes frames of an audio stream.
+type Writer struct {
+	writer io.Writer
+	buf    []byte
+}
+
+// NewWriter creates a new writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		writer: w,
+	}
+}
+
+// WriteFrame writes a frame with the specified header and body, which is the
+// remainder of the frame. If the frame is protected, body must begin with the
+// parity-check word, which will be replaced with a newly computed one.
+func (w *Writer) WriteFrame(h FrameHeader, body []byte) error {
+	buf, err := h.AppendFrame(w.buf[:0], body)
+	if err != nil {
+		return err
+	}
+	w.buf = buf
+	_, err = w.writer.Write(buf)
+	return err
+}
+
+// WriteRaw writes a complete frame, including the header, as-is. The frame is
+// not validated other than ensuring it begins with a syncword.
+func (w *Writer) WriteRaw(raw []byte) error {
+	if !IsSyncword(raw) {
+		return ErrUnsynchronized
+	}
+	_, err := w.writer.Write(raw)
+	return err
+}
+
+// Flush flushes the underlying writer if it has a Flush method (e.g.,
+// [bufio.Writer]).
+func (w *Writer) Flush() error {
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package mp3
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var out bytes.Buffer
+		bw := bufio.NewWriter(&out)
+		w := NewWriter(bw)
+
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			if err := w.WriteFrame(*r.Header(), r.Raw()[FrameHeaderSize:]); err != nil {
+				t.Fatalf("write frame: %v", err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+
+		start := Sync(buf)
+		if exp := buf[start : start+out.Len()]; !bytes.Equal(out.Bytes(), exp) {
+			t.Errorf("written stream differs")
+		}
+	})
+}
+
+func TestWriterRaw(t *testing.T) {
+	testStreams(t, func(t *testing.T, buf []byte) {
+		var out bytes.Buffer
+		w := NewWriter(&out)
+
+		r := NewReader(bytes.NewReader(buf), 16384)
+		for r.Next() {
+			if err := w.WriteRaw(r.Raw()); err != nil {
+				t.Fatalf("write frame: %v", err)
+			}
+		}
+
+		start := Sync(buf)
+		if exp := buf[start : start+out.Len()]; !bytes.Equal(out.Bytes(), exp) {
+			t.Errorf("written stream differs")
+		}
+	})
+	if err := NewWriter(io.Discard).WriteRaw([]byte{0, 0, 0, 0}); err != ErrUnsynchronized {
+		t.Errorf("expected error %v, got %v", ErrUnsynchronized, err)
+	}
+}
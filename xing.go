@@ -0,0 +1,406 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XingHeader is the VBR header written by the Xing encoder (and others, like
+// LAME) in place of the main data of the first frame of a stream.
+type XingHeader struct {
+	// Tag is "Xing", or "Info" for CBR streams.
+	Tag string
+	// Flags indicates which of the optional fields are present.
+	Flags XingFlags
+	// Frames is the number of frames in the stream.
+	Frames uint32
+	// Bytes is the size of the stream in bytes.
+	Bytes uint32
+	// TOC contains the offset at each percentage of the duration, in units of
+	// 1/256 of Bytes.
+	TOC [100]byte
+	// Quality is an encoder-specific quality indicator, from 0 (best) to 100
+	// (worst).
+	Quality uint32
+}
+
+type XingFlags uint32
+
+const (
+	XingFrames XingFlags = 1 << iota
+	XingBytes
+	XingTOC
+	XingQuality
+)
+
+// ParseXingHeader parses the Xing header from the frame data following the
+// header. If the frame does not contain a valid Xing header, false is
+// returned.
+func ParseXingHeader(f FrameHeader, body []byte) (*XingHeader, bool) {
+	x, _, ok := parseXingHeader(f, body)
+	return x, ok
+}
+
+// parseXingHeader parses the Xing header, also returning the offset in body of
+// the end of the header.
+func parseXingHeader(f FrameHeader, body []byte) (*XingHeader, int, bool) {
+	off, ok := f.SideInfoSize()
+	if !ok {
+		return nil, 0, false
+	}
+	if f.Protection {
+		off += 2
+	}
+	if len(body) < off+8 {
+		return nil, 0, false
+	}
+	x := new(XingHeader)
+	switch tag := string(body[off : off+4]); tag {
+	case "Xing", "Info":
+		x.Tag = tag
+	default:
+		return nil, 0, false
+	}
+	x.Flags = XingFlags(binary.BigEndian.Uint32(body[off+4:]))
+	off += 8
+	if x.Flags&XingFrames != 0 {
+		if len(body) < off+4 {
+			return nil, 0, false
+		}
+		x.Frames = binary.BigEndian.Uint32(body[off:])
+		off += 4
+	}
+	if x.Flags&XingBytes != 0 {
+		if len(body) < off+4 {
+			return nil, 0, false
+		}
+		x.Bytes = binary.BigEndian.Uint32(body[off:])
+		off += 4
+	}
+	if x.Flags&XingTOC != 0 {
+		if len(body) < off+len(x.TOC) {
+			return nil, 0, false
+		}
+		off += copy(x.TOC[:], body[off:])
+	}
+	if x.Flags&XingQuality != 0 {
+		if len(body) < off+4 {
+			return nil, 0, false
+		}
+		x.Quality = binary.BigEndian.Uint32(body[off:])
+		off += 4
+	}
+	return x, off, true
+}
+
+// IsCBR returns true if the tag is "Info", which indicates a CBR stream.
+func (x XingHeader) IsCBR() bool {
+	return x.Tag == "Info"
+}
+
+// AppendBinary appends the encoded Xing header, which starts with the tag and
+// only contains the fields indicated by the flags. The tag must be "Xing" or
+// "Info".
+func (x XingHeader) AppendBinary(b []byte) ([]byte, error) {
+	if x.Tag != "Xing" && x.Tag != "Info" {
+		return b, errors.New("invalid xing tag " + strconv.Quote(x.Tag))
+	}
+	b = append(b, x.Tag...)
+	b = binary.BigEndian.AppendUint32(b, uint32(x.Flags))
+	if x.Flags&XingFrames != 0 {
+		b = binary.BigEndian.AppendUint32(b, x.Frames)
+	}
+	if x.Flags&XingBytes != 0 {
+		b = binary.BigEndian.AppendUint32(b, x.Bytes)
+	}
+	if x.Flags&XingTOC != 0 {
+		b = append(b, x.TOC[:]...)
+	}
+	if x.Flags&XingQuality != 0 {
+		b = binary.BigEndian.AppendUint32(b, x.Quality)
+	}
+	return b, nil
+}
+
+// xingHeaderMaxSize is the size of a Xing header with all fields.
+const xingHeaderMaxSize = 4 + 4 + 4 + 4 + 100 + 4
+
+// WriteXingHeader writes the stream in r, which is size bytes long, to w with a
+// Xing frame containing x inserted before the first frame, replacing any
+// existing Xing, Info, or VBRI frame. Tags and other data before the first
+// frame and after the last one are copied as-is.
+//
+// The number of frames, the number of bytes, and the table of contents are
+// computed from the stream. If the tag is empty, it is set to "Xing". The Xing
+// frame has the same format as the first frame, but the bitrate may be
+// increased to fit the header. The stream must be Layer III.
+func WriteXingHeader(w io.Writer, r io.ReaderAt, size int64, x XingHeader) error {
+	rd := NewReader(io.NewSectionReader(r, 0, size), indexBufferSize)
+	var (
+		ix    Index
+		start int64 // of the first frame
+		audio int64 // of the first frame after the existing info frame, if any
+		h     FrameHeader
+		frame []byte
+	)
+	for n := 0; rd.Next(); n++ {
+		off := rd.Offset() - int64(len(rd.Raw()))
+		if n == 0 {
+			start = off
+			if rd.IsInfoFrame() {
+				continue
+			}
+		}
+		if frame == nil {
+			var err error
+			if h, frame, err = xingFrame(*rd.Header()); err != nil {
+				return err
+			}
+			audio = off
+		}
+		if err := ix.add(int64(len(frame))+off-audio, len(rd.Raw()), rd.Header()); err != nil {
+			return err
+		}
+	}
+	if err := rd.Err(); err != nil {
+		return err
+	}
+	if frame == nil {
+		return errors.New("no frames")
+	}
+
+	if x.Tag == "" {
+		x.Tag = "Xing"
+	}
+	x.Flags |= XingFrames | XingBytes | XingTOC
+	x.Frames = uint32(len(ix.sizes))
+	x.Bytes = uint32(int64(len(frame)) + rd.Offset() - audio)
+	x.TOC = ix.toc(0, int64(x.Bytes))
+
+	off, _ := h.SideInfoSize()
+	off += FrameHeaderSize
+	if h.Protection {
+		off += 2
+	}
+	b, err := x.AppendBinary(nil)
+	if err != nil {
+		return err
+	}
+	copy(frame[off:], b)
+	frame, err = h.AppendFrame(nil, frame[FrameHeaderSize:])
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, io.NewSectionReader(r, 0, start)); err != nil {
+		return err
+	}
+	if _, err := w.Write(frame); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(r, audio, size-audio)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// xingFrame creates an empty frame for a Xing header for a stream starting with
+// a frame with the specified header.
+func xingFrame(f FrameHeader) (FrameHeader, []byte, error) {
+	if f.Layer != MPEGLayerIII {
+		return f, nil, errors.New("xing header requires layer 3")
+	}
+	off, ok := f.SideInfoSize()
+	if !ok {
+		return f, nil, errors.New("not a valid layer 3 frame")
+	}
+	off += FrameHeaderSize + xingHeaderMaxSize
+	if f.Protection {
+		off += 2
+	}
+	f.Padding = false
+	if f.BitrateIndex == BitrateIndexFree {
+		f.BitrateIndex++
+	}
+	for ; f.BitrateIndex < 15; f.BitrateIndex++ {
+		if size, ok := f.FrameSize(); ok && size >= off {
+			return f, make([]byte, size), nil
+		}
+	}
+	return f, nil, errors.New("frame too small for xing header")
+}
+
+// LAMEHeader is the extension written by LAME following the [XingHeader].
+type LAMEHeader struct {
+	// Encoder is the short encoder version string (e.g., "LAME3.100").
+	Encoder string
+	// Revision is the revision of the LAME tag.
+	Revision uint8
+	// VBRMethod is the VBR method used by the encoder.
+	VBRMethod uint8
+	// Lowpass is the lowpass filter frequency in Hz.
+	Lowpass int
+	// PeakAmplitude is the peak signal amplitude, where 1.0 is the maximal
+	// signal amplitude storeable in 16 bit PCM.
+	PeakAmplitude float32
+	// ReplayGainTrack and ReplayGainAlbum are the track (radio) and album
+	// (audiophile) ReplayGain adjustments in dB.
+	ReplayGainTrack, ReplayGainAlbum float32
+	// ReplayGainTrackOriginator and ReplayGainAlbumOriginator indicate how the
+	// ReplayGain adjustments were set (1 for the artist, 2 for the user, 3 for
+	// the model, or 4 for the simple RMS average), or 0 if they are not set.
+	ReplayGainTrackOriginator, ReplayGainAlbumOriginator uint8
+	// EncodingFlags contains the nspsytune, nssafejoint, nogap flags.
+	EncodingFlags uint8
+	// ATHType is the absolute threshold of hearing type.
+	ATHType uint8
+	// Bitrate is the ABR bitrate, CBR bitrate, or minimum VBR bitrate in
+	// kbit/s, or 255 if greater than or equal to 255 kbit/s.
+	Bitrate uint8
+	// EncoderDelay and PaddingSamples are the number of samples added by the
+	// encoder at the start and end of the stream.
+	EncoderDelay, PaddingSamples uint16
+	// Misc contains the noise shaping, stereo mode, unwise settings, and source
+	// sampling frequency.
+	Misc uint8
+	// MP3Gain is the gain applied by MP3Gain in 1.5 dB steps.
+	MP3Gain int8
+	// Preset contains the surround info and preset used.
+	Preset uint16
+	// MusicLength is the length of the stream in bytes, including the first
+	// frame.
+	MusicLength uint32
+	// MusicCRC is the CRC-16 of the audio data after the first frame.
+	MusicCRC uint16
+}
+
+// lameHeaderSize is the size of the LAME extension.
+const lameHeaderSize = 36
+
+// ParseLAMEHeader parses the LAME extension following the [XingHeader] from the
+// frame data following the header. If the frame does not contain a Xing header
+// followed by a valid LAME extension with a correct checksum, false is
+// returned.
+func ParseLAMEHeader(f FrameHeader, body []byte) (*LAMEHeader, bool) {
+	_, off, ok := parseXingHeader(f, body)
+	if !ok || len(body) < off+lameHeaderSize {
+		return nil, false
+	}
+	b := body[off : off+lameHeaderSize]
+
+	var hdr [FrameHeaderSize]byte
+	f.encode(hdr[:])
+	crc := crc16LAME(0, hdr[:])
+	crc = crc16LAME(crc, body[:off+lameHeaderSize-2])
+	if crc != binary.BigEndian.Uint16(b[34:]) {
+		return nil, false
+	}
+
+	l := &LAMEHeader{
+		Encoder:        strings.TrimRight(string(b[:9]), "\x00 "),
+		Revision:       b[9] >> 4,
+		VBRMethod:      b[9] & 0xF,
+		Lowpass:        int(b[10]) * 100,
+		PeakAmplitude:  float32(binary.BigEndian.Uint32(b[11:])) / (1 << 23),
+		EncodingFlags:  b[19] >> 4,
+		ATHType:        b[19] & 0xF,
+		Bitrate:        b[20],
+		EncoderDelay:   uint16(b[21])<<4 | uint16(b[22])>>4,
+		PaddingSamples: uint16(b[22]&0xF)<<8 | uint16(b[23]),
+		Misc:           b[24],
+		MP3Gain:        int8(b[25]),
+		Preset:         binary.BigEndian.Uint16(b[26:]),
+		MusicLength:    binary.BigEndian.Uint32(b[28:]),
+		MusicCRC:       binary.BigEndian.Uint16(b[32:]),
+	}
+	l.ReplayGainTrack, l.ReplayGainTrackOriginator = replayGain(binary.BigEndian.Uint16(b[15:]), 1)
+	l.ReplayGainAlbum, l.ReplayGainAlbumOriginator = replayGain(binary.BigEndian.Uint16(b[17:]), 2)
+	return l, true
+}
+
+// Gapless returns the number of samples to trim from the start and end of the
+// decoded stream for gapless playback. The values are in samples (per channel)
+// at the sampling frequency of the stream, and are counted from the first frame
+// following the one containing the LAME extension. They do not include any
+// additional delay introduced by the decoder.
+func (l *LAMEHeader) Gapless() (delay, padding int) {
+	return int(l.EncoderDelay), int(l.PaddingSamples)
+}
+
+// TrackGain returns the track (radio) ReplayGain adjustment in dB. If it is not
+// set, false is returned.
+func (l *LAMEHeader) TrackGain() (db float32, ok bool) {
+	return l.ReplayGainTrack, l.ReplayGainTrackOriginator != 0
+}
+
+// AlbumGain returns the album (audiophile) ReplayGain adjustment in dB. If it
+// is not set, false is returned.
+func (l *LAMEHeader) AlbumGain() (db float32, ok bool) {
+	return l.ReplayGainAlbum, l.ReplayGainAlbumOriginator != 0
+}
+
+// Peak returns the peak signal amplitude (see [LAMEHeader.PeakAmplitude]). If
+// it is not set, false is returned.
+func (l *LAMEHeader) Peak() (float32, bool) {
+	return l.PeakAmplitude, l.PeakAmplitude != 0
+}
+
+// EncoderName returns the encoder version string (e.g., "LAME3.100" or
+// "Lavc58.13"), or an empty string if it is empty or contains non-printable
+// characters.
+func (l *LAMEHeader) EncoderName() string {
+	for _, c := range []byte(l.Encoder) {
+		if c < ' ' || c > '~' {
+			return ""
+		}
+	}
+	return l.Encoder
+}
+
+// DetectEncoder reads the first frame of the stream in r, which is size bytes
+// long, and returns the encoder name from the LAME extension (see
+// [LAMEHeader.EncoderName]). If the first frame does not contain a LAME
+// extension with an encoder name, false is returned.
+func DetectEncoder(r io.ReaderAt, size int64) (string, bool) {
+	rd := NewReader(io.NewSectionReader(r, 0, size), indexBufferSize)
+	if !rd.Next() {
+		return "", false
+	}
+	l, ok := ParseLAMEHeader(*rd.Header(), rd.Raw()[FrameHeaderSize:])
+	if !ok {
+		return "", false
+	}
+	name := l.EncoderName()
+	return name, name != ""
+}
+
+// replayGain decodes a ReplayGain adjustment in dB and its originator. If the
+// name code is not the expected one, it is not set.
+func replayGain(v uint16, name uint8) (float32, uint8) {
+	if uint8(v>>13) != name {
+		return 0, 0
+	}
+	db := float32(v&0x1FF) / 10
+	if v&0x200 != 0 {
+		db = -db
+	}
+	return db, uint8(v >> 10 & 0b111)
+}
+
+// crc16LAME updates crc with b using the reflected CRC-16 used by LAME.
+func crc16LAME(crc uint16, b []byte) uint16 {
+	for _, c := range b {
+		crc ^= uint16(c)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,284 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+// testInfoFrame builds a frame containing a Xing header, optionally followed by
+// a LAME extension (with the checksum filled in).
+func testInfoFrame(h FrameHeader, x XingHeader, lame []byte) []byte {
+	size, ok := h.FrameSize()
+	if !ok {
+		panic("invalid header")
+	}
+	frame := make([]byte, size)
+	h.encode(frame)
+
+	off, _ := h.SideInfoSize()
+	off += FrameHeaderSize
+	if h.Protection {
+		off += 2
+	}
+	off += copy(frame[off:], x.Tag)
+	binary.BigEndian.PutUint32(frame[off:], uint32(x.Flags))
+	off += 4
+	if x.Flags&XingFrames != 0 {
+		binary.BigEndian.PutUint32(frame[off:], x.Frames)
+		off += 4
+	}
+	if x.Flags&XingBytes != 0 {
+		binary.BigEndian.PutUint32(frame[off:], x.Bytes)
+		off += 4
+	}
+	if x.Flags&XingTOC != 0 {
+		off += copy(frame[off:], x.TOC[:])
+	}
+	if x.Flags&XingQuality != 0 {
+		binary.BigEndian.PutUint32(frame[off:], x.Quality)
+		off += 4
+	}
+	if lame != nil {
+		off += copy(frame[off:], lame[:lameHeaderSize-2])
+		binary.BigEndian.PutUint16(frame[off:], crc16LAME(0, frame[:off]))
+	}
+	return frame
+}
+
+// testLAME is a LAME extension with the values checked by TestParseLAMEHeader.
+var testLAME = []byte{
+	'L', 'A', 'M', 'E', '3', '.', '1', '0', '0',
+	0x13,                   // revision 1, vbr method 3
+	0xA0,                   // lowpass 16000 Hz
+	0x00, 0x80, 0x00, 0x00, // peak 1.0
+	0x2E, 0x41, // track gain -6.5 dB
+	0x4C, 0x0C, // album gain +1.2 dB
+	0x24,             // flags, ath type
+	0x80,             // bitrate 128
+	0x24, 0x04, 0x80, // delay 576, padding 1152
+	0x00,       // misc
+	0x00,       // mp3gain
+	0x00, 0x00, // preset
+	0x00, 0x00, 0x30, 0x39, // music length 12345
+	0xBE, 0xEF, // music crc
+	0x00, 0x00, // tag crc
+}
+
+func TestParseLAMEHeader(t *testing.T) {
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	x := XingHeader{Tag: "Xing", Flags: XingFrames | XingBytes | XingTOC | XingQuality, Frames: 100, Bytes: 41800, Quality: 57}
+	for i := range x.TOC {
+		x.TOC[i] = byte(i * 256 / 100)
+	}
+	frame := testInfoFrame(h, x, testLAME)
+
+	xh, ok := ParseXingHeader(h, frame[FrameHeaderSize:])
+	if !ok {
+		t.Fatalf("failed to parse xing header")
+	}
+	if *xh != x {
+		t.Errorf("expected xing header %+v, got %+v", x, *xh)
+	}
+
+	l, ok := ParseLAMEHeader(h, frame[FrameHeaderSize:])
+	if !ok {
+		t.Fatalf("failed to parse lame header")
+	}
+	if exp := (LAMEHeader{
+		Encoder:         "LAME3.100",
+		Revision:        1,
+		VBRMethod:       3,
+		Lowpass:         16000,
+		PeakAmplitude:   1,
+		ReplayGainTrack: -6.5,
+		ReplayGainAlbum: 1.2,
+		EncodingFlags:   2,
+		ATHType:         4,
+		Bitrate:         128,
+		EncoderDelay:    576,
+		PaddingSamples:  1152,
+		MusicLength:     12345,
+		MusicCRC:        0xBEEF,
+
+		ReplayGainTrackOriginator: 3,
+		ReplayGainAlbumOriginator: 3,
+	}); *l != exp {
+		t.Errorf("expected lame header %+v, got %+v", exp, *l)
+	}
+
+	if delay, padding := l.Gapless(); delay != 576 || padding != 1152 {
+		t.Errorf("expected gapless (576, 1152), got (%d, %d)", delay, padding)
+	}
+
+	if db, ok := l.TrackGain(); !ok || db != -6.5 {
+		t.Errorf("expected track gain -6.5 dB, got %v (ok=%t)", db, ok)
+	}
+	if db, ok := l.AlbumGain(); !ok || db != 1.2 {
+		t.Errorf("expected album gain 1.2 dB, got %v (ok=%t)", db, ok)
+	}
+	if peak, ok := l.Peak(); !ok || peak != 1 {
+		t.Errorf("expected peak 1, got %v (ok=%t)", peak, ok)
+	}
+
+	// not set, and the wrong name code
+	lame := bytes.Clone(testLAME)
+	copy(lame[11:], []byte{0, 0, 0, 0, 0x00, 0x00, 0x2E, 0x41})
+	if l, ok := ParseLAMEHeader(h, testInfoFrame(h, x, lame)[FrameHeaderSize:]); !ok {
+		t.Errorf("failed to parse lame header")
+	} else {
+		if db, ok := l.TrackGain(); ok {
+			t.Errorf("expected no track gain, got %v", db)
+		}
+		if db, ok := l.AlbumGain(); ok {
+			t.Errorf("expected no album gain, got %v", db)
+		}
+		if peak, ok := l.Peak(); ok {
+			t.Errorf("expected no peak, got %v", peak)
+		}
+	}
+
+	frame[FrameHeaderSize+32+120+5]++
+	if _, ok := ParseLAMEHeader(h, frame[FrameHeaderSize:]); ok {
+		t.Errorf("expected lame header with incorrect checksum to fail")
+	}
+}
+
+func TestIsInfoFrame(t *testing.T) {
+	buf, err := fs.ReadFile(testdata, "testdata/layer3/he_44khz.mp3")
+	if err != nil {
+		panic(err)
+	}
+	var h FrameHeader
+	if err := h.UnmarshalBinary(buf[:FrameHeaderSize]); err != nil {
+		panic(err)
+	}
+	h.BitrateIndex = 9
+	buf = append(testInfoFrame(h, XingHeader{Tag: "Info"}, nil), buf...)
+
+	var n int
+	r := NewReader(bytes.NewReader(buf), 16384)
+	for r.Next() {
+		n++
+		if act, exp := r.IsInfoFrame(), n == 1; act != exp {
+			t.Errorf("frame %d: expected info frame %t, got %t", n, exp, act)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Errorf("read frames: %v", err)
+	}
+}
+
+func TestXingIsCBR(t *testing.T) {
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeSingleChannel}
+	for _, tc := range []struct {
+		Tag string
+		CBR bool
+	}{
+		{"Info", true},
+		{"Xing", false},
+	} {
+		x, ok := ParseXingHeader(h, testInfoFrame(h, XingHeader{Tag: tc.Tag}, nil)[FrameHeaderSize:])
+		if !ok {
+			t.Fatalf("%s: parse xing header", tc.Tag)
+		}
+		if x.IsCBR() != tc.CBR {
+			t.Errorf("%s: expected cbr %t, got %t", tc.Tag, tc.CBR, x.IsCBR())
+		}
+	}
+}
+
+func TestWriteXingHeader(t *testing.T) {
+	for _, vbri := range []bool{false, true} {
+		stream, _ := testVBR(vbri)
+		tag := make([]byte, id3v1Size)
+		copy(tag, "TAG")
+		buf := slices.Concat([]byte("ID3\x04\x00\x00\x00\x00\x00\x02\x00\x00"), stream, tag)
+
+		var out bytes.Buffer
+		if err := WriteXingHeader(&out, bytes.NewReader(buf), int64(len(buf)), XingHeader{Flags: XingQuality, Quality: 42}); err != nil {
+			t.Fatalf("vbri=%t: write xing header: %v", vbri, err)
+		}
+
+		var (
+			frames [][]byte
+			starts []int
+		)
+		r := NewReader(bytes.NewReader(out.Bytes()), 16384)
+		for r.Next() {
+			frames = append(frames, bytes.Clone(r.Raw()))
+			starts = append(starts, int(r.Offset())-len(r.Raw()))
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("vbri=%t: read frames: %v", vbri, err)
+		}
+		if n := len(frames); n != 401 {
+			t.Fatalf("vbri=%t: expected 401 frames, got %d", vbri, n)
+		}
+		var h FrameHeader
+		h.decode(frames[0])
+		x, ok := ParseXingHeader(h, frames[0][FrameHeaderSize:])
+		if !ok {
+			t.Fatalf("vbri=%t: expected xing header in first frame", vbri)
+		}
+		if x.Tag != "Xing" || x.Flags != XingFrames|XingBytes|XingTOC|XingQuality || x.Frames != 400 || x.Quality != 42 {
+			t.Errorf("vbri=%t: incorrect xing header %+v", vbri, x)
+		}
+		if exp := uint32(out.Len() - id3v1Size - starts[0]); x.Bytes != exp {
+			t.Errorf("vbri=%t: expected %d bytes, got %d", vbri, exp, x.Bytes)
+		}
+		for i, act := range x.TOC {
+			if exp := byte((starts[1+i*400/100] - starts[0]) * 256 / int(x.Bytes)); act != exp {
+				t.Errorf("vbri=%t: toc %d: expected %d, got %d", vbri, i, exp, act)
+			}
+		}
+		info, _ := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}.FrameSize()
+		if exp := slices.Concat(buf[:12], frames[0], stream[info:], tag); !bytes.Equal(out.Bytes(), exp) {
+			t.Errorf("vbri=%t: incorrect output", vbri)
+		}
+
+		var again bytes.Buffer
+		if err := WriteXingHeader(&again, bytes.NewReader(out.Bytes()), int64(out.Len()), *x); err != nil {
+			t.Fatalf("vbri=%t: update xing header: %v", vbri, err)
+		}
+		if !bytes.Equal(again.Bytes(), out.Bytes()) {
+			t.Errorf("vbri=%t: expected updating the xing header to be idempotent", vbri)
+		}
+	}
+
+	buf, err := fs.ReadFile(testdata, "testdata/layer2/fl10.mp2")
+	if err != nil {
+		panic(err)
+	}
+	if err := WriteXingHeader(io.Discard, bytes.NewReader(buf), int64(len(buf)), XingHeader{}); err == nil {
+		t.Errorf("expected error for layer 2")
+	}
+}
+
+func TestDetectEncoder(t *testing.T) {
+	h := FrameHeader{ID: MPEGVersion1, Layer: MPEGLayerIII, BitrateIndex: 9, Mode: ModeJointStereo}
+	for _, tc := range []struct {
+		Encoder string
+		Name    string
+	}{
+		{"LAME3.100", "LAME3.100"},
+		{"Lavc58.13", "Lavc58.13"},
+		{"Lavf\x00\x00\x00\x00\x00", "Lavf"},
+		{"\x00\x00\x00\x00\x00\x00\x00\x00\x00", ""},
+		{"LAME\xff\x01\x02\x03\x04", ""},
+	} {
+		lame := slices.Clone(testLAME)
+		copy(lame, tc.Encoder)
+		buf := testInfoFrame(h, XingHeader{Tag: "Info"}, lame)
+		if name, ok := DetectEncoder(bytes.NewReader(buf), int64(len(buf))); name != tc.Name || ok != (tc.Name != "") {
+			t.Errorf("%q: expected %q, got %q (ok=%t)", tc.Encoder, tc.Name, name, ok)
+		}
+	}
+	buf := testInfoFrame(h, XingHeader{Tag: "Info"}, nil)
+	if name, ok := DetectEncoder(bytes.NewReader(buf), int64(len(buf))); ok {
+		t.Errorf("expected no encoder without lame extension, got %q", name)
+	}
+}